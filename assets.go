@@ -0,0 +1,359 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+)
+
+const assetIDLen = sha256.Size
+const maxAssetNameLen = 32
+const maxAssetMetadataSize = 256
+
+// AssetIssuance defines a new asset. It rides on the input whose outpoint
+// the asset ID is derived from, an outpoint is spent only once so every ID
+// is issued once.
+type AssetIssuance struct {
+	Name     string
+	Supply   int
+	Metadata []byte
+}
+
+// AssetAmount is a quantity of an issued asset held by an output
+type AssetAmount struct {
+	ID     []byte
+	Amount int
+}
+
+// AssetCoin is a spendable output holding an asset
+type AssetCoin struct {
+	Coin
+	Asset AssetAmount
+}
+
+// NewAssetID derives the ID of an asset issued by the input spending the
+// output txID:outIdx
+func NewAssetID(txID []byte, outIdx int) []byte {
+	data := append([]byte("asset"), txID...)
+	data = binary.BigEndian.AppendUint64(data, uint64(outIdx))
+	hash := sha256.Sum256(data)
+
+	return hash[:]
+}
+
+// check enforces the issuance rules
+func (ai AssetIssuance) check() error {
+	if ai.Name == "" || len(ai.Name) > maxAssetNameLen {
+		return fmt.Errorf("asset name must have between 1 and %d bytes", maxAssetNameLen)
+	}
+	if ai.Supply <= 0 {
+		return errors.New("asset supply must be positive")
+	}
+	if len(ai.Metadata) > maxAssetMetadataSize {
+		return fmt.Errorf("asset metadata has %d bytes, the limit is %d", len(ai.Metadata), maxAssetMetadataSize)
+	}
+
+	return nil
+}
+
+// IsAsset checks whether the output holds an asset
+func (out *TXOutput) IsAsset() bool {
+	return out.Asset != nil
+}
+
+// checkAsset enforces the asset rules on an output
+func (out *TXOutput) checkAsset() error {
+	if out.IsData() {
+		return errors.New("data outputs cannot hold assets")
+	}
+	if len(out.Asset.ID) != assetIDLen {
+		return fmt.Errorf("asset ID must be %d bytes", assetIDLen)
+	}
+	if out.Asset.Amount <= 0 {
+		return errors.New("asset amount must be positive")
+	}
+
+	return nil
+}
+
+// addAssetAmount adds an asset amount to the total of an asset, refusing
+// totals that overflow
+func addAssetAmount(totals map[string]int, id string, amount int) error {
+	if totals[id] > math.MaxInt-amount {
+		return fmt.Errorf("asset %s overflows", id)
+	}
+	totals[id] += amount
+
+	return nil
+}
+
+// CheckAssets checks that the transaction conserves every asset: what its
+// inputs hold plus what it issues equals what its outputs hold. Inputs may
+// spend outputs of the unconfirmed parents.
+func (bc *Blockchain) CheckAssets(tx *Transaction, parents map[string]*Transaction) error {
+	inputs := make(map[string]int)
+	outputs := make(map[string]int)
+
+	if tx.IsCoinbase() {
+		for _, out := range tx.Vout {
+			if out.IsAsset() {
+				return errors.New("coinbase transactions cannot hold assets")
+			}
+		}
+		if tx.Vin[0].Issuance != nil {
+			return errors.New("coinbase transactions cannot issue assets")
+		}
+
+		return nil
+	}
+
+	for _, vin := range tx.Vin {
+		if vin.Issuance != nil {
+			if err := vin.Issuance.check(); err != nil {
+				return err
+			}
+			if err := addAssetAmount(inputs, hex.EncodeToString(NewAssetID(vin.Txid, vin.Vout)), vin.Issuance.Supply); err != nil {
+				return err
+			}
+		}
+
+		out, err := bc.FindSpentOutput(vin.Txid, vin.Vout, parents)
+		if err != nil {
+			return err
+		}
+		if out.IsAsset() {
+			if err := addAssetAmount(inputs, hex.EncodeToString(out.Asset.ID), out.Asset.Amount); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, out := range tx.Vout {
+		if out.IsAsset() {
+			if err := addAssetAmount(outputs, hex.EncodeToString(out.Asset.ID), out.Asset.Amount); err != nil {
+				return err
+			}
+		}
+	}
+
+	for id, amount := range inputs {
+		if outputs[id] != amount {
+			return fmt.Errorf("transaction %x does not conserve asset %s, %d in and %d out", tx.ID, id, amount, outputs[id])
+		}
+	}
+	for id, amount := range outputs {
+		if _, ok := inputs[id]; !ok {
+			return fmt.Errorf("transaction %x does not conserve asset %s, 0 in and %d out", tx.ID, id, amount)
+		}
+	}
+
+	return nil
+}
+
+// FindAsset scans the chain for the issuance of an asset
+func (bc *Blockchain) FindAsset(assetID []byte) (*AssetIssuance, error) {
+	bci := bc.Iterator()
+
+	for {
+		block := bci.Next()
+
+		for _, tx := range block.Transactions {
+			for _, vin := range tx.Vin {
+				if vin.Issuance != nil && bytes.Equal(NewAssetID(vin.Txid, vin.Vout), assetID) {
+					return vin.Issuance, nil
+				}
+			}
+		}
+
+		if len(block.PrevBlockHash) == 0 {
+			break
+		}
+	}
+
+	return nil, fmt.Errorf("asset %x is not issued", assetID)
+}
+
+// FindAssetCoins returns the mature outputs locked to a public key hash
+// holding assets that no pending transaction spends
+func (u UTXOSet) FindAssetCoins(pubKeyHash []byte) []AssetCoin {
+	var coins []AssetCoin
+	db := u.Blockchain.db
+	pending := Mempool{u.Blockchain}.SpentOutputs()
+	height := u.Blockchain.GetBestHeight() + 1
+
+	iter := db.NewIterator(nil, nil)
+	for iter.Next() {
+		key := iter.Key()
+		// Check if the key has the chainstate prefix
+		if len(key) > len(utxoBucket) && string(key[:len(utxoBucket)]) == utxoBucket {
+			txID := string(key[len(utxoBucket)+1:])
+			outs := DeserializeOutputs(iter.Value())
+			if !outs.IsMature(height) {
+				continue
+			}
+
+			for i, out := range outs.Outputs {
+				if _, ok := pending[txID+":"+strconv.Itoa(outs.Index(i))]; ok {
+					continue
+				}
+				if out.IsAsset() && out.IsLockedWithKey(pubKeyHash) {
+					coins = append(coins, AssetCoin{Coin{txID, outs.Index(i), out.Value}, *out.Asset})
+				}
+			}
+		}
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		log.Panic(err)
+	}
+
+	return coins
+}
+
+// GetAssetBalances returns the amount of every asset a public key hash
+// holds by hex asset ID
+func (u UTXOSet) GetAssetBalances(pubKeyHash []byte) map[string]int {
+	balances := make(map[string]int)
+	for _, coin := range u.FindAssetCoins(pubKeyHash) {
+		balances[hex.EncodeToString(coin.Asset.ID)] += coin.Asset.Amount
+	}
+
+	return balances
+}
+
+// fundFee adds coins of pubKeyHash to inputs until value covers the fee of
+// a transaction with the inputs and outputs outputs, it returns the new
+// value. At least one coin is taken when inputs is empty.
+func fundFee(inputs []TXInput, prevOutputs []TXOutput, value, outputs int, pubKeyHash []byte, selection CoinSelection, UTXOSet *UTXOSet) ([]TXInput, []TXOutput, int) {
+	// The selector only knows the fee of its own coins, it has to pay for
+	// the inputs already chosen as well
+	needed := selection.FeeRate.Fee(len(inputs), 0) - selection.FeeRate.Fee(0, 0) - value
+	if needed <= 0 && len(inputs) > 0 && value >= selection.FeeRate.Fee(len(inputs), outputs) {
+		return inputs, prevOutputs, value
+	}
+	if needed < 0 {
+		needed = 0
+	}
+
+	coins, err := selection.Selector.Select(UTXOSet.FindCoins(pubKeyHash), needed, outputs, selection.FeeRate)
+	if err != nil {
+		log.Panic("ERROR: Not enough funds to pay the fee")
+	}
+	for _, coin := range coins {
+		txID, err := hex.DecodeString(coin.TxID)
+		if err != nil {
+			log.Panic(err)
+		}
+
+		inputs = append(inputs, TXInput{Txid: txID, Vout: coin.Index})
+		prevOutputs = append(prevOutputs, TXOutput{coin.Value, pubKeyHash, nil, nil, nil})
+		value += coin.Value
+	}
+
+	return inputs, prevOutputs, value
+}
+
+// NewIssuanceTransaction issues supply units of a new asset to an address.
+// The first input spent from the issuer's wallet address carries the
+// issuance and determines the asset ID.
+func NewIssuanceTransaction(from, to string, issuance AssetIssuance, selection CoinSelection, UTXOSet *UTXOSet) *Transaction {
+	if err := issuance.check(); err != nil {
+		log.Panic(err)
+	}
+	wallets, err := NewWallets()
+	if err != nil {
+		log.Panic(err)
+	}
+	wallet := wallets.GetWallet(from)
+	pubKeyHash := AddressToPubKeyHash(from)
+
+	inputs, prevOutputs, value := fundFee(nil, nil, 0, 2, pubKeyHash, selection, UTXOSet)
+	inputs[0].Issuance = &issuance
+	assetID := NewAssetID(inputs[0].Txid, inputs[0].Vout)
+
+	assetOutput := NewTXOutput(0, to)
+	assetOutput.Asset = &AssetAmount{assetID, issuance.Supply}
+	outputs := []TXOutput{*assetOutput}
+	if change := value - selection.FeeRate.Fee(len(inputs), 2); change > 0 {
+		outputs = append(outputs, *NewTXOutput(change, from))
+	}
+
+	tx := Transaction{nil, inputs, outputs, 0}
+	tx.ID = tx.Hash()
+	tx.SignPrevOutputs(wallet.PrivateKey, prevOutputs, SigHashAll)
+
+	return &tx
+}
+
+// NewAssetTransaction sends amount units of an asset from a wallet address.
+// The asset change goes back to the sender together with the coins left
+// after paying the fee.
+func NewAssetTransaction(from, to string, assetID []byte, amount int, lockTime int64, sequence uint32, selection CoinSelection, UTXOSet *UTXOSet) *Transaction {
+	wallets, err := NewWallets()
+	if err != nil {
+		log.Panic(err)
+	}
+	wallet := wallets.GetWallet(from)
+	pubKeyHash := AddressToPubKeyHash(from)
+
+	var coins []AssetCoin
+	for _, coin := range UTXOSet.FindAssetCoins(pubKeyHash) {
+		if bytes.Equal(coin.Asset.ID, assetID) {
+			coins = append(coins, coin)
+		}
+	}
+	sort.SliceStable(coins, func(i, j int) bool { return coins[i].Asset.Amount > coins[j].Asset.Amount })
+
+	var inputs []TXInput
+	var prevOutputs []TXOutput
+	acc, value := 0, 0
+	for _, coin := range coins {
+		if acc >= amount {
+			break
+		}
+		txID, err := hex.DecodeString(coin.TxID)
+		if err != nil {
+			log.Panic(err)
+		}
+
+		asset := coin.Asset
+		inputs = append(inputs, TXInput{Txid: txID, Vout: coin.Index})
+		prevOutputs = append(prevOutputs, TXOutput{coin.Value, pubKeyHash, nil, &asset, nil})
+		acc += asset.Amount
+		value += coin.Value
+	}
+	if acc < amount {
+		log.Panicf("ERROR: Not enough of asset %x, %d available", assetID, acc)
+	}
+
+	// Payment, asset change and coin change
+	inputs, prevOutputs, value = fundFee(inputs, prevOutputs, value, 3, pubKeyHash, selection, UTXOSet)
+	for i := range inputs {
+		inputs[i].Sequence = sequence
+	}
+
+	payment := NewTXOutput(0, to)
+	payment.Asset = &AssetAmount{assetID, amount}
+	outputs := []TXOutput{*payment}
+	if acc > amount {
+		assetChange := NewTXOutput(0, from)
+		assetChange.Asset = &AssetAmount{assetID, acc - amount}
+		outputs = append(outputs, *assetChange)
+	}
+	if change := value - selection.FeeRate.Fee(len(inputs), len(outputs)+1); change > 0 {
+		outputs = append(outputs, *NewTXOutput(change, from))
+	}
+
+	tx := Transaction{nil, inputs, outputs, lockTime}
+	tx.ID = tx.Hash()
+	tx.SignPrevOutputs(wallet.PrivateKey, prevOutputs, SigHashAll)
+
+	return &tx
+}
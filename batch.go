@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// Payment is a single recipient of a batch transaction
+type Payment struct {
+	Address string `json:"address"`
+	Amount  int    `json:"amount"`
+}
+
+// PaymentList collects payments from repeated ADDRESS:AMOUNT flags
+type PaymentList []Payment
+
+func (pl *PaymentList) String() string {
+	var pairs []string
+	for _, payment := range *pl {
+		pairs = append(pairs, fmt.Sprintf("%s:%d", payment.Address, payment.Amount))
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+// Set parses an ADDRESS:AMOUNT pair
+func (pl *PaymentList) Set(value string) error {
+	parts := strings.Split(value, ":")
+	if len(parts) != 2 {
+		return fmt.Errorf("payment %q is not ADDRESS:AMOUNT", value)
+	}
+	amount, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return fmt.Errorf("payment %q has an invalid amount", value)
+	}
+
+	*pl = append(*pl, Payment{strings.TrimSpace(parts[0]), amount})
+	return nil
+}
+
+// ReadPayments loads payments from a JSON array of {"address", "amount"}
+// objects or from CSV lines of address,amount with an optional header
+func ReadPayments(path string) ([]Payment, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var payments []Payment
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte("[")) {
+		err = json.Unmarshal(content, &payments)
+		return payments, err
+	}
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		amount, err := strconv.Atoi(strings.TrimSpace(record[1]))
+		if err != nil {
+			if line == 1 {
+				continue // header
+			}
+			return nil, fmt.Errorf("line %d: invalid amount %q", line, record[1])
+		}
+		payments = append(payments, Payment{strings.TrimSpace(record[0]), amount})
+	}
+
+	return payments, nil
+}
+
+// checkPayments validates the recipients of a batch and returns their total
+func checkPayments(payments []Payment) int {
+	if len(payments) == 0 {
+		log.Panic("ERROR: No recipients")
+	}
+
+	total := 0
+	for _, payment := range payments {
+		if !ValidateAddress(payment.Address) {
+			log.Panicf("ERROR: Recipient address %s is not valid", payment.Address)
+		}
+		if payment.Amount <= 0 {
+			log.Panicf("ERROR: Amount for %s must be positive", payment.Address)
+		}
+		total += payment.Amount
+		if total < 0 {
+			log.Panic("ERROR: Total amount overflows")
+		}
+	}
+
+	return total
+}
+
+// NewBatchTransaction creates a transaction paying every recipient from a
+// single wallet address with one change output back to it. The coins are
+// picked by the selection strategy, which also sets the fee.
+func NewBatchTransaction(from string, payments []Payment, lockTime int64, sequence uint32, selection CoinSelection, UTXOSet *UTXOSet) *Transaction {
+	wallets, err := NewWallets()
+	if err != nil {
+		log.Panic(err)
+	}
+	wallet := wallets.GetWallet(from)
+
+	pt := NewRawTransaction(from, payments, lockTime, sequence, selection, UTXOSet)
+	pt.Tx.SignPrevOutputs(wallet.PrivateKey, pt.PrevOutputs, SigHashAll)
+
+	return &pt.Tx
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"crypto/ecdsa"
 	"crypto/sha256"
 	"encoding/gob"
 	"log"
@@ -15,6 +16,12 @@ type Block struct {
 	PrevBlockHash []byte
 	Hash          []byte
 	Stake 		  int64
+	Height        int
+	// Versioned blocks commit to a Merkle root of their transactions and
+	// are signed by the proposer's key
+	Version   int
+	Proposer  []byte
+	Signature []byte
 }
 
 // Serialize serializes the block
@@ -30,11 +37,16 @@ func (b *Block) Serialize() []byte {
 	return result.Bytes()
 }
 
-// HashTransactions returns a hash of the transactions in the block
+// HashTransactions returns a hash of the transactions in the block, the
+// Merkle root for versioned blocks
 func (b *Block) HashTransactions() []byte {
 	var txHashes [][]byte
 	var txHash [32]byte
 
+	if b.Version >= merkleBlockVersion {
+		return MerkleRoot(b.merkleLeaves())
+	}
+
 	for _, tx := range b.Transactions {
 		txHashes = append(txHashes, tx.ID)
 	}
@@ -43,31 +55,57 @@ func (b *Block) HashTransactions() []byte {
 	return txHash[:]
 }
 
-// NewBlock creates and returns Block
-func NewBlock(transactions []*Transaction, prevBlockHash []byte, stake int64) *Block {
-	block := &Block{time.Now().Unix(), transactions, prevBlockHash, []byte{}, stake}
-	pos := NewProofOfStake(block)
+func (b *Block) merkleLeaves() [][]byte {
+	var leaves [][]byte
+
+	for _, tx := range b.Transactions {
+		leaves = append(leaves, tx.Serialize())
+	}
+
+	return leaves
+}
+
+// MerkleProof returns the proof that the transaction at index is part of
+// the block
+func (b *Block) MerkleProof(index int) MerkleProof {
+	return NewMerkleProof(b.merkleLeaves(), index)
+}
+
+// NewBlock creates and returns Block signed by the proposer
+func NewBlock(transactions []*Transaction, prevBlockHash []byte, height int, stake int64, proposer ecdsa.PrivateKey) *Block {
+	block := &Block{time.Now().Unix(), transactions, prevBlockHash, []byte{}, stake, height, merkleBlockVersion, encodePubKey(&proposer.PublicKey), nil}
+	pos := NewProofOfStake(block, chainParams().StakeThreshold)
 	hash := pos.Run()
 
 	block.Hash = hash
+	block.Signature = signHash(proposer, hash)
 
 	return block
 }
 
 // NewGenesisBlock creates and returns genesis Block
-func NewGenesisBlock(coinbase *Transaction) *Block {
-	return NewBlock([]*Transaction{coinbase}, []byte{}, 51)
+func NewGenesisBlock(coinbase *Transaction, proposer ecdsa.PrivateKey) *Block {
+	return NewBlock([]*Transaction{coinbase}, []byte{}, 0, 51, proposer)
 }
 
-// DeserializeBlock deserializes a block
+// DeserializeBlock deserializes a block, refusing data beyond the block
+// size limit before decoding it and blocks whose transactions break the
+// limits after
 func DeserializeBlock(d []byte) *Block {
 	var block Block
 
+	if len(d) > chainParams().MaxBlockSize {
+		log.Panicf("ERROR: Block has %d bytes, at most %d are allowed", len(d), chainParams().MaxBlockSize)
+	}
+
 	decoder := gob.NewDecoder(bytes.NewReader(d))
 	err := decoder.Decode(&block)
 	if err != nil {
 		log.Panic(err)
 	}
+	if err := block.CheckLimits(); err != nil {
+		log.Panicf("ERROR: %s", err)
+	}
 
 	return &block
 }
@@ -8,12 +8,15 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sort"
 
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
 )
 
 const dbFile = "blockchain.db"
 const genesisCoinbaseData = "The Times 03/Jan/2009 Chancellor on brink of second bailout for banks"
+const medianTimeBlocks = 11
 
 // Blockchain implements interactions with a DB
 type Blockchain struct {
@@ -21,30 +24,32 @@ type Blockchain struct {
 	db  *leveldb.DB
 }
 
-// CreateBlockchain creates a new blockchain DB
-func CreateBlockchain(address string) *Blockchain {
+// CreateBlockchain creates a new blockchain DB, the genesis block is signed
+// by proposer
+func CreateBlockchain(address string, proposer ecdsa.PrivateKey) *Blockchain {
 	if dbExists() {
 		fmt.Println("Blockchain already exists.")
 		os.Exit(1)
 	}
 
-	cbtx := NewCoinbaseTX(address, genesisCoinbaseData)
-	genesis := NewGenesisBlock(cbtx)
+	cbtx := NewCoinbaseTX(address, genesisCoinbaseData, 0)
+	genesis := NewGenesisBlock(cbtx, proposer)
 	db, err := leveldb.OpenFile(dbFile, nil)
 	if err != nil {
 		log.Panic(err)
 	}
 
-	err = db.Put(genesis.Hash, genesis.Serialize(), nil)
-	if err != nil {
-		log.Panic(err)
-	}
-	err = db.Put([]byte("l"), genesis.Hash, nil)
+	bc := Blockchain{genesis.Hash, db}
+
+	batch := new(leveldb.Batch)
+	batch.Put(genesis.Hash, genesis.Serialize())
+	batch.Put([]byte("l"), genesis.Hash)
+	bc.indexFilter(batch, genesis)
+	err = db.Write(batch, nil)
 	if err != nil {
 		log.Panic(err)
 	}
 
-	bc := Blockchain{genesis.Hash, db}
 	return &bc
 }
 
@@ -54,17 +59,30 @@ func NewBlockchain() *Blockchain {
 		fmt.Println("No existing blockchain found. Create one first.")
 		os.Exit(1)
 	}
-	db, err := leveldb.OpenFile(dbFile, nil)
+	bc, err := OpenBlockchain(dbFile)
 	if err != nil {
 		log.Panic(err)
 	}
+
+	return bc
+}
+
+// OpenBlockchain opens the blockchain database at path
+func OpenBlockchain(path string) (*Blockchain, error) {
+	db, err := leveldb.OpenFile(path, &opt.Options{ErrorIfMissing: true})
+	if err != nil {
+		return nil, err
+	}
 	tip, err := db.Get([]byte("l"), nil)
 	if err != nil {
-		log.Panic(err)
+		db.Close()
+		return nil, err
 	}
 
-	bc := Blockchain{tip, db}
-	return &bc
+	bc := &Blockchain{tip, db}
+	bc.activateParams()
+
+	return bc, nil
 }
 
 // FindTransaction finds a transaction by its ID
@@ -97,11 +115,17 @@ func (bc *Blockchain) FindUTXO() map[string]TXOutputs {
 	for {
 		block := bci.Next()
 
-		for _, tx := range block.Transactions {
+		// Walk the block backwards too, so spends by later transactions
+		// are known before the outputs of earlier ones in the same block
+		for i := len(block.Transactions) - 1; i >= 0; i-- {
+			tx := block.Transactions[i]
 			txID := hex.EncodeToString(tx.ID)
 
 		Outputs:
 			for outIdx, out := range tx.Vout {
+				if out.IsData() {
+					continue
+				}
 				if spentTXOs[txID] != nil {
 					for _, spentOutIdx := range spentTXOs[txID] {
 						if spentOutIdx == outIdx {
@@ -111,7 +135,9 @@ func (bc *Blockchain) FindUTXO() map[string]TXOutputs {
 				}
 
 				outs := UTXO[txID]
-				outs.Outputs = append(outs.Outputs, out)
+				outs.Height = block.Height
+				outs.Coinbase = tx.IsCoinbase()
+				outs.Add(outIdx, out)
 				UTXO[txID] = outs
 			}
 
@@ -136,14 +162,28 @@ func (bc *Blockchain) Iterator() *BlockchainIterator {
 }
 
 
-// MineBlock mines a new block with the provided transactions
-func (bc *Blockchain) MineBlock(transactions []*Transaction, stake int64) *Block {
+// MineBlock mines a new block with the provided transactions, signed by the
+// proposer
+func (bc *Blockchain) MineBlock(transactions []*Transaction, stake int64, proposer ecdsa.PrivateKey) *Block {
 	var lastHash []byte
 
+	if len(transactions) == 0 || !transactions[0].IsCoinbase() {
+		log.Panic("ERROR: The first transaction of a block must be its coinbase")
+	}
+
+	// Transactions may spend outputs of earlier transactions in the block,
+	// those are their parents. Schnorr signatures of the whole block are
+	// verified in one batch.
+	parents := make(map[string]*Transaction)
+	batch := &SchnorrBatch{}
 	for _, tx := range transactions {
-		if !bc.VerifyTransaction(tx) {
+		if !bc.verifyTransaction(tx, parents, batch) {
 			log.Panic("ERROR: Invalid transaction")
 		}
+		parents[hex.EncodeToString(tx.ID)] = tx
+	}
+	if !batch.Verify() {
+		log.Panic("ERROR: Invalid Schnorr signature in block")
 	}
 
 	lastHash, err := bc.db.Get([]byte("l"), nil)
@@ -151,23 +191,315 @@ func (bc *Blockchain) MineBlock(transactions []*Transaction, stake int64) *Block
 		log.Panic(err)
 	}
 
-	newBlock := NewBlock(transactions, lastHash, stake)
+	lastBlock, err := bc.GetBlock(lastHash)
+	if err != nil {
+		log.Panic(err)
+	}
+	height := lastBlock.Height + 1
+	medianTime := bc.MedianTimePast(lastHash)
+	if threshold := bc.ChainParamsAt(height).StakeThreshold; stake <= int64(threshold) {
+		log.Panicf("ERROR: Stake %d does not exceed the stake threshold %d", stake, threshold)
+	}
 
-	err = bc.db.Put(newBlock.Hash, newBlock.Serialize(), nil)
+	spent := make(map[string]bool)
+	parents = make(map[string]*Transaction)
+	for i, tx := range transactions {
+		if i > 0 && tx.IsCoinbase() {
+			log.Panic("ERROR: A block can only have one coinbase transaction")
+		}
+		if err := tx.CheckID(); err != nil {
+			log.Panic(err)
+		}
+		if err := tx.CheckOutputs(); err != nil {
+			log.Panic(err)
+		}
+		if err := bc.CheckInputs(tx, parents); err != nil {
+			log.Panic(err)
+		}
+		if err := bc.CheckAssets(tx, parents); err != nil {
+			log.Panic(err)
+		}
+		if err := bc.CheckNFTs(tx, parents); err != nil {
+			log.Panic(err)
+		}
+		if err := bc.CheckContract(tx, parents); err != nil {
+			log.Panic(err)
+		}
+		if err := bc.CheckGovernance(tx, height); err != nil {
+			log.Panic(err)
+		}
+		if err := bc.CheckName(tx, height, parents); err != nil {
+			log.Panic(err)
+		}
+		for _, vin := range tx.Vin {
+			if !tx.IsCoinbase() && spent[outpointKey(vin.Txid, vin.Vout)] {
+				log.Panicf("ERROR: Output %x:%d is spent twice in the block", vin.Txid, vin.Vout)
+			}
+			spent[outpointKey(vin.Txid, vin.Vout)] = true
+		}
+		if err := bc.CheckTimeLocks(tx, height, medianTime, parents); err != nil {
+			log.Panic(err)
+		}
+		if err := bc.CheckCoinbaseMaturity(tx, height, parents); err != nil {
+			log.Panic(err)
+		}
+		parents[hex.EncodeToString(tx.ID)] = tx
+	}
+
+	fees, err := bc.BlockFees(transactions[1:])
 	if err != nil {
 		log.Panic(err)
 	}
-	err = bc.db.Put([]byte("l"), newBlock.Hash, nil)
+	if err := bc.CheckCoinbase(transactions[0], height, fees); err != nil {
+		log.Panic(err)
+	}
+
+	newBlock := NewBlock(transactions, lastHash, height, stake, proposer)
+	if err := newBlock.CheckLimits(); err != nil {
+		log.Panic(err)
+	}
+
+	dbBatch := new(leveldb.Batch)
+	dbBatch.Put(newBlock.Hash, newBlock.Serialize())
+	dbBatch.Put([]byte("l"), newBlock.Hash)
+	bc.indexFilter(dbBatch, newBlock)
+	bc.executeContracts(dbBatch, newBlock)
+	bc.executeGovernance(dbBatch, newBlock)
+	bc.executeNames(dbBatch, newBlock)
+	err = bc.db.Write(dbBatch, nil)
 	if err != nil {
 		log.Panic(err)
 	}
 
 	bc.tip = newBlock.Hash
+	bc.activateParams()
 	return newBlock
 }
 
+// DisconnectTip removes the tip block from the chain: the contract storage,
+// governance state and names it changed are restored and the UTXO set
+// rebuilt for the previous tip. The
+// block stays in the database and is returned so its transactions can go
+// back to the mempool.
+func (bc *Blockchain) DisconnectTip() (*Block, error) {
+	block, err := bc.GetBlock(bc.tip)
+	if err != nil {
+		return nil, err
+	}
+	if len(block.PrevBlockHash) == 0 {
+		return nil, errors.New("the genesis block cannot be disconnected")
+	}
+
+	batch := new(leveldb.Batch)
+	bc.disconnectContracts(batch, &block)
+	bc.disconnectGovernance(batch, &block)
+	bc.disconnectNames(batch, &block)
+	batch.Put([]byte("l"), block.PrevBlockHash)
+	if err := bc.db.Write(batch, nil); err != nil {
+		return nil, err
+	}
+	bc.tip = block.PrevBlockHash
+	bc.activateParams()
+
+	UTXOSet{bc}.Reindex()
+
+	return &block, nil
+}
+
+// CheckInputs checks that every input spends a distinct unspent output and
+// that the inputs hold at least the value of the outputs
+func (bc *Blockchain) CheckInputs(tx *Transaction, parents map[string]*Transaction) error {
+	if tx.IsCoinbase() {
+		return nil
+	}
+	if len(tx.Vin) == 0 {
+		return fmt.Errorf("transaction %x has no inputs", tx.ID)
+	}
+
+	seen := make(map[string]bool)
+	for _, vin := range tx.Vin {
+		if seen[outpointKey(vin.Txid, vin.Vout)] {
+			return fmt.Errorf("transaction %x spends %x:%d twice", tx.ID, vin.Txid, vin.Vout)
+		}
+		seen[outpointKey(vin.Txid, vin.Vout)] = true
+	}
+
+	inputValue, err := bc.InputValue(tx, parents)
+	if err != nil {
+		return err
+	}
+	outputValue, err := tx.OutputValue()
+	if err != nil {
+		return err
+	}
+	if inputValue < outputValue {
+		return fmt.Errorf("transaction %x pays %d but its inputs only hold %d", tx.ID, outputValue, inputValue)
+	}
+
+	return nil
+}
+
+// InputValue returns the value of the outputs the inputs of a transaction
+// spend, which may be outputs of parents
+func (bc *Blockchain) InputValue(tx *Transaction, parents map[string]*Transaction) (int, error) {
+	value := 0
+	for _, vin := range tx.Vin {
+		out, err := bc.FindSpentOutput(vin.Txid, vin.Vout, parents)
+		if err != nil {
+			return 0, err
+		}
+		if value, err = addValue(value, out.Value); err != nil {
+			return 0, fmt.Errorf("transaction %x inputs: %s", tx.ID, err)
+		}
+	}
+
+	return value, nil
+}
+
+// TransactionFee returns what a transaction pays, the value of its inputs
+// minus that of its outputs
+func (bc *Blockchain) TransactionFee(tx *Transaction, parents map[string]*Transaction) (int, error) {
+	inputValue, err := bc.InputValue(tx, parents)
+	if err != nil {
+		return 0, err
+	}
+	outputValue, err := tx.OutputValue()
+	if err != nil {
+		return 0, err
+	}
+
+	return inputValue - outputValue, nil
+}
+
+// BlockFees returns what the transactions of a block other than its
+// coinbase pay together, they may spend outputs of earlier ones
+func (bc *Blockchain) BlockFees(txs []*Transaction) (int, error) {
+	fees := 0
+	parents := make(map[string]*Transaction)
+
+	for _, tx := range txs {
+		fee, err := bc.TransactionFee(tx, parents)
+		if err != nil {
+			return 0, err
+		}
+		if fees, err = addValue(fees, fee); err != nil {
+			return 0, err
+		}
+		parents[hex.EncodeToString(tx.ID)] = tx
+	}
+
+	return fees, nil
+}
+
+// CheckCoinbase checks that the coinbase of the block at height pays no
+// more than the subsidy and the fees of the other transactions
+func (bc *Blockchain) CheckCoinbase(coinbase *Transaction, height, fees int) error {
+	value, err := coinbase.OutputValue()
+	if err != nil {
+		return err
+	}
+	reward, err := addValue(bc.ChainParamsAt(height).Subsidy, fees)
+	if err != nil {
+		return err
+	}
+	if value > reward {
+		return fmt.Errorf("coinbase %x pays %d, the subsidy and fees are %d", coinbase.ID, value, reward)
+	}
+
+	return nil
+}
+
+// CheckCoinbaseMaturity checks that no input spends a coinbase output that
+// is still immature at height
+func (bc *Blockchain) CheckCoinbaseMaturity(tx *Transaction, height int, parents map[string]*Transaction) error {
+	if tx.IsCoinbase() {
+		return nil
+	}
+
+	UTXOSet := UTXOSet{bc}
+	for _, vin := range tx.Vin {
+		if _, ok := parents[hex.EncodeToString(vin.Txid)]; ok {
+			continue
+		}
+		outs, err := UTXOSet.GetOutputs(vin.Txid)
+		if err != nil {
+			return err
+		}
+		if !outs.IsMature(height) {
+			return fmt.Errorf("input %x:%d spends a coinbase that matures at height %d", vin.Txid, vin.Vout, outs.Height+chainParams().CoinbaseMaturity)
+		}
+	}
+
+	return nil
+}
+
+// GetBlock finds a block by its hash and returns it
+func (bc *Blockchain) GetBlock(blockHash []byte) (Block, error) {
+	blockData, err := bc.db.Get(blockHash, nil)
+	if err != nil {
+		return Block{}, errors.New("Block is not found")
+	}
+
+	return *DeserializeBlock(blockData), nil
+}
+
+// GetBestHeight returns the height of the latest block
+func (bc *Blockchain) GetBestHeight() int {
+	lastBlock, err := bc.GetBlock(bc.tip)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return lastBlock.Height
+}
+
+// GetBlockAtHeight walks back from the tip to the block at height
+func (bc *Blockchain) GetBlockAtHeight(height int) (Block, error) {
+	bci := bc.Iterator()
+
+	for {
+		block := bci.Next()
+
+		if block.Height == height {
+			return *block, nil
+		}
+
+		if len(block.PrevBlockHash) == 0 || block.Height < height {
+			break
+		}
+	}
+
+	return Block{}, fmt.Errorf("no block at height %d", height)
+}
+
+// MedianTimePast returns the median timestamp of the last blocks ending at
+// blockHash, time locks are compared against it instead of the block time
+func (bc *Blockchain) MedianTimePast(blockHash []byte) int64 {
+	var timestamps []int64
+	bci := &BlockchainIterator{blockHash, bc.db}
+
+	for len(timestamps) < medianTimeBlocks {
+		block := bci.Next()
+		timestamps = append(timestamps, block.Timestamp)
+
+		if len(block.PrevBlockHash) == 0 {
+			break
+		}
+	}
+
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i] < timestamps[j] })
+
+	return timestamps[len(timestamps)/2]
+}
+
 // SignTransaction signs inputs of a Transaction
 func (bc *Blockchain) SignTransaction(tx *Transaction, privKey ecdsa.PrivateKey) {
+	bc.SignTransactionWithHashType(tx, privKey, SigHashAll)
+}
+
+// SignTransactionWithHashType signs inputs of a Transaction with the given
+// signature hash type
+func (bc *Blockchain) SignTransactionWithHashType(tx *Transaction, privKey ecdsa.PrivateKey, hashType byte) {
 	prevTXs := make(map[string]Transaction)
 
 	for _, vin := range tx.Vin {
@@ -178,11 +510,32 @@ func (bc *Blockchain) SignTransaction(tx *Transaction, privKey ecdsa.PrivateKey)
 		prevTXs[hex.EncodeToString(prevTX.ID)] = prevTX
 	}
 
-	tx.Sign(privKey, prevTXs)
+	tx.SignWithHashType(privKey, prevTXs, hashType)
+}
+
+// FindSpentOutput returns the output an input spends, looking in the
+// unconfirmed parents before the UTXO set
+func (bc *Blockchain) FindSpentOutput(txID []byte, outIdx int, parents map[string]*Transaction) (*TXOutput, error) {
+	parent, ok := parents[hex.EncodeToString(txID)]
+	if !ok {
+		return UTXOSet{bc}.FindOutput(txID, outIdx)
+	}
+
+	if outIdx < 0 || outIdx >= len(parent.Vout) || parent.Vout[outIdx].IsData() {
+		return nil, fmt.Errorf("output %x:%d does not exist", txID, outIdx)
+	}
+
+	return &parent.Vout[outIdx], nil
 }
 
 // VerifyTransaction verifies transaction input signatures
 func (bc *Blockchain) VerifyTransaction(tx *Transaction) bool {
+	return bc.verifyTransaction(tx, nil, nil)
+}
+
+// verifyTransaction verifies the signatures of a transaction whose inputs
+// may spend unconfirmed parents
+func (bc *Blockchain) verifyTransaction(tx *Transaction, parents map[string]*Transaction, batch *SchnorrBatch) bool {
 	if tx.IsCoinbase() {
 		return true
 	}
@@ -190,6 +543,10 @@ func (bc *Blockchain) VerifyTransaction(tx *Transaction) bool {
 	prevTXs := make(map[string]Transaction)
 
 	for _, vin := range tx.Vin {
+		if parent, ok := parents[hex.EncodeToString(vin.Txid)]; ok {
+			prevTXs[hex.EncodeToString(parent.ID)] = *parent
+			continue
+		}
 		prevTX, err := bc.FindTransaction(vin.Txid)
 		if err != nil {
 			log.Panic(err)
@@ -197,7 +554,7 @@ func (bc *Blockchain) VerifyTransaction(tx *Transaction) bool {
 		prevTXs[hex.EncodeToString(prevTX.ID)] = prevTX
 	}
 
-	return tx.Verify(prevTXs)
+	return tx.VerifyWithBatch(prevTXs, batch)
 }
 
 func dbExists() bool {
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+)
+
+// channelDelay is the number of blocks a party closing a channel on its own
+// waits for its coins, the time the counterparty has to punish a revoked
+// commitment
+const channelDelay = 5
+
+// ChannelParty is one side of a payment channel
+type ChannelParty struct {
+	Address string
+	Balance int
+
+	// Secrets are the revocation secrets of the party's own commitments,
+	// one per state, Revoked the counterparty secrets it has been given
+	Secrets [][]byte
+	Revoked [][]byte
+
+	// Commitment is the latest commitment transaction of the party, signed
+	// by both sides so it can close the channel alone
+	Commitment Transaction
+}
+
+// Channel is a two-party payment channel funded by a 2-of-2 multisig
+// output. Payments update the balances off chain: both parties sign new
+// commitment transactions, then each revokes its previous commitment by
+// handing over its revocation secret.
+type Channel struct {
+	FundingTxID []byte
+	FundingVout int
+	Capacity    int
+	Script      []byte
+	State       int
+	Parties     [2]ChannelParty
+	ClosingTxID []byte
+}
+
+// ID returns the hex funding transaction ID identifying the channel
+func (c *Channel) ID() string {
+	return hex.EncodeToString(c.FundingTxID)
+}
+
+// IsClosed checks whether a closing transaction was submitted
+func (c *Channel) IsClosed() bool {
+	return len(c.ClosingTxID) > 0
+}
+
+// Party returns the index of the party with address or -1
+func (c *Channel) Party(address string) int {
+	for i, party := range c.Parties {
+		if party.Address == address {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func newRevocationSecret() []byte {
+	secret := make([]byte, sha256.Size)
+	if _, err := rand.Read(secret); err != nil {
+		log.Panic(err)
+	}
+
+	return secret
+}
+
+// NewChannel creates a channel between two wallet addresses funded with
+// amount by from, and the transaction funding it. Both commitments are
+// signed before the funding transaction, so from can always get its coins
+// back.
+func NewChannel(from, to string, amount int, wallets *Wallets, UTXOSet *UTXOSet) (*Channel, *Transaction) {
+	if amount <= 0 {
+		log.Panic("ERROR: Channel capacity must be positive")
+	}
+	if from == to {
+		log.Panic("ERROR: A channel needs two different addresses")
+	}
+
+	var pubKeys [][]byte
+	for _, address := range []string{from, to} {
+		wallet, ok := wallets.Wallets[address]
+		if !ok {
+			log.Panicf("ERROR: Address %s is not in the wallet file", address)
+		}
+		pubKeys = append(pubKeys, encodePubKey(&wallet.PrivateKey.PublicKey))
+	}
+	script, err := NewMultiSigScript(2, pubKeys)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	scriptAddress := fmt.Sprintf("%s", script.GetAddress())
+	funding := NewBatchTransaction(from, []Payment{{scriptAddress, amount}}, 0, 0, DefaultCoinSelection, UTXOSet)
+
+	channel := &Channel{
+		FundingTxID: funding.ID,
+		Capacity:    amount,
+		Script:      script.Serialize(),
+	}
+	for i, out := range funding.Vout {
+		if bytes.Equal(out.PubKeyHash, script.Hash()) {
+			channel.FundingVout = i
+		}
+	}
+	channel.Parties[0] = ChannelParty{Address: from, Balance: amount, Secrets: [][]byte{newRevocationSecret()}}
+	channel.Parties[1] = ChannelParty{Address: to, Secrets: [][]byte{newRevocationSecret()}}
+	channel.signCommitments(wallets)
+
+	return channel, funding
+}
+
+// fundingOutput returns the multisig output the channel spends
+func (c *Channel) fundingOutput() TXOutput {
+	script, err := DeserializeRedeemScript(c.Script)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return TXOutput{c.Capacity, script.Hash(), nil, nil, nil}
+}
+
+// fundingInput returns an unsigned input spending the funding output
+func (c *Channel) fundingInput() TXInput {
+	return TXInput{Txid: c.FundingTxID, Vout: c.FundingVout, RedeemScript: c.Script, Witness: make([][]byte, 2)}
+}
+
+// delayedScript returns the script of the output owner pays itself in its
+// commitment of a state, revoked by the owner's secret of that state
+func (c *Channel) delayedScript(owner int, secret []byte) *RedeemScript {
+	revocationHash := sha256.Sum256(secret)
+	ownerHash := AddressToPubKeyHash(c.Parties[owner].Address)
+	counterpartyHash := AddressToPubKeyHash(c.Parties[1-owner].Address)
+
+	script, err := NewRevocableScript(revocationHash[:], ownerHash, counterpartyHash, channelDelay)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return script
+}
+
+// newCommitment builds the unsigned commitment of owner for the current
+// state: the owner's balance waits in a revocable output, the
+// counterparty's is paid to it directly
+func (c *Channel) newCommitment(owner int) Transaction {
+	var outputs []TXOutput
+
+	local := c.Parties[owner]
+	remote := c.Parties[1-owner]
+	if local.Balance > 0 {
+		script := c.delayedScript(owner, local.Secrets[c.State])
+		outputs = append(outputs, TXOutput{local.Balance, script.Hash(), nil, nil, nil})
+	}
+	if remote.Balance > 0 {
+		outputs = append(outputs, *NewTXOutput(remote.Balance, remote.Address))
+	}
+
+	tx := Transaction{nil, []TXInput{c.fundingInput()}, outputs, 0}
+	tx.ID = tx.Hash()
+
+	return tx
+}
+
+// signFunding signs a transaction spending the funding output with the
+// keys of both parties
+func (c *Channel) signFunding(tx *Transaction, wallets *Wallets) {
+	prevOutputs := []TXOutput{c.fundingOutput()}
+
+	for _, party := range c.Parties {
+		wallet, ok := wallets.Wallets[party.Address]
+		if !ok {
+			log.Panicf("ERROR: Address %s is not in the wallet file", party.Address)
+		}
+		tx.SignPrevOutputs(wallet.PrivateKey, prevOutputs, SigHashAll)
+	}
+}
+
+// signCommitments gives both parties their signed commitment of the
+// current state
+func (c *Channel) signCommitments(wallets *Wallets) {
+	for owner := range c.Parties {
+		commitment := c.newCommitment(owner)
+		c.signFunding(&commitment, wallets)
+		c.Parties[owner].Commitment = commitment
+	}
+}
+
+// Pay moves amount from the party with address to the other one. Both
+// sign the commitments of the new state before revoking the old ones.
+func (c *Channel) Pay(from string, amount int, wallets *Wallets) error {
+	payer := c.Party(from)
+	if payer < 0 {
+		return fmt.Errorf("%s is not a party of channel %s", from, c.ID())
+	}
+	if c.IsClosed() {
+		return fmt.Errorf("channel %s is closed", c.ID())
+	}
+	if amount <= 0 || amount > c.Parties[payer].Balance {
+		return fmt.Errorf("amount must be between 1 and the balance of %d", c.Parties[payer].Balance)
+	}
+
+	c.Parties[payer].Balance -= amount
+	c.Parties[1-payer].Balance += amount
+	for i := range c.Parties {
+		c.Parties[i].Secrets = append(c.Parties[i].Secrets, newRevocationSecret())
+	}
+	c.State++
+	c.signCommitments(wallets)
+
+	for i := range c.Parties {
+		revoked := c.Parties[i].Secrets[c.State-1]
+		c.Parties[1-i].Revoked = append(c.Parties[1-i].Revoked, revoked)
+	}
+
+	return nil
+}
+
+// NewCooperativeClose creates the transaction both parties sign to close
+// the channel at once with the current balances
+func (c *Channel) NewCooperativeClose(wallets *Wallets) *Transaction {
+	var outputs []TXOutput
+
+	for _, party := range c.Parties {
+		if party.Balance > 0 {
+			outputs = append(outputs, *NewTXOutput(party.Balance, party.Address))
+		}
+	}
+
+	tx := Transaction{nil, []TXInput{c.fundingInput()}, outputs, 0}
+	tx.ID = tx.Hash()
+	c.signFunding(&tx, wallets)
+
+	return &tx
+}
+
+// NewChannelSweep creates a transaction paying the party with address
+// every revocable output of the channel it can claim: its own balance
+// once the delay after its commitment confirmed has passed, and the
+// counterparty's balance at once when the counterparty closed with a
+// revoked commitment.
+func (c *Channel) NewChannelSweep(address string, wallets *Wallets, UTXOSet *UTXOSet) (*Transaction, error) {
+	var inputs []TXInput
+
+	party := c.Party(address)
+	if party < 0 {
+		return nil, fmt.Errorf("%s is not a party of channel %s", address, c.ID())
+	}
+	wallet, ok := wallets.Wallets[address]
+	if !ok {
+		return nil, fmt.Errorf("address %s is not in the wallet file", address)
+	}
+
+	claim := func(script *RedeemScript, sequence uint32, secret []byte) int {
+		acc, validOutputs := UTXOSet.FindSpendableOutputs(script.Hash(), math.MaxInt)
+		redeemScript := script.Serialize()
+
+		for txid, outs := range validOutputs {
+			txID, err := hex.DecodeString(txid)
+			if err != nil {
+				log.Panic(err)
+			}
+
+			for _, out := range outs {
+				witness := make([][]byte, 2)
+				if secret != nil {
+					witness = append(witness, secret)
+				}
+				input := TXInput{Txid: txID, Vout: out, Sequence: sequence, RedeemScript: redeemScript, Witness: witness}
+				inputs = append(inputs, input)
+			}
+		}
+
+		return acc
+	}
+
+	own := c.Parties[party]
+	acc := claim(c.delayedScript(party, own.Secrets[c.State]), RelativeLockBlocks(channelDelay), nil)
+	for _, secret := range own.Revoked {
+		acc += claim(c.delayedScript(1-party, secret), 0, secret)
+	}
+	if len(inputs) == 0 {
+		return nil, errors.New("no channel outputs to claim")
+	}
+
+	tx := Transaction{nil, inputs, []TXOutput{*NewTXOutput(acc, address)}, 0}
+	tx.ID = tx.Hash()
+	UTXOSet.Blockchain.SignTransaction(&tx, wallet.PrivateKey)
+
+	return &tx, nil
+}
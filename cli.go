@@ -13,12 +13,88 @@ type CLI struct{}
 func (cli *CLI) printUsage() {
 	fmt.Println("Usage:")
 	fmt.Println("  createblockchain -address ADDRESS - Create a blockchain and send genesis block reward to ADDRESS")
-	fmt.Println("  createwallet - Generates a new key-pair and saves it into the wallet file")
+	fmt.Println("  contribute -tx TX -from FROM -amount AMOUNT [-sighash ALL|ANYONECANPAY] - Add and sign inputs from FROM to TX")
+	fmt.Println("  createwallet [-hd] [-change] - Generates a new key-pair and saves it into the wallet file, with -hd the next key")
+	fmt.Println("    of the receive or change chain of the HD seed, which is created along with its mnemonic first")
+	fmt.Println("  crowdfund -to TO -goal GOAL - Create a transaction paying GOAL to TO that contributors fund")
+	fmt.Println("  createmultisig -required M -keys KEY1,KEY2,... - Print the M-of-N multisig address for wallet addresses or hex public keys")
+	fmt.Println("  createschnorraddress -keys KEY1,KEY2,... - Add a Schnorr address for the MuSig aggregate of wallet addresses or hex keys")
+	fmt.Println("  addmultisigaddress -required M -keys KEY1,KEY2,... - Add an M-of-N multisig address to the wallet file")
 	fmt.Println("  getbalance -address ADDRESS - Get balance of ADDRESS")
+	fmt.Println("  getpubkey -address ADDRESS - Print the hex public key of a wallet ADDRESS for sharing with cosigners")
 	fmt.Println("  listaddresses - Lists all addresses from the wallet file")
+	fmt.Println("  migratewallet [-sweep -stake STAKE] - Add compressed key addresses for legacy wallets, -sweep moves legacy coins to them")
+	fmt.Println("  mine -address ADDRESS -stake STAKE - Mine the pending transactions and send the block reward to ADDRESS")
+	fmt.Println("  notarize -file FILE -from FROM -stake STAKE - Anchor the hash of FILE in the blockchain")
 	fmt.Println("  printchain - Print all the blocks of the blockchain")
 	fmt.Println("  reindexutxo - Rebuilds the UTXO set")
-	fmt.Println("  send -from FROM -to TO -amount AMOUNT - Send AMOUNT of coins from FROM address to TO")
+	fmt.Println("  verifynotarization -file FILE - Print the block and time proving FILE existed")
+	fmt.Println("  send -from FROM -to TO -amount AMOUNT [-asset ASSET] - Send AMOUNT of coins, or units of ASSET, from FROM address to TO,")
+	fmt.Println("    an address or a registered name")
+	fmt.Println("    [-locktime HEIGHT|TIME] [-relativeblocks N | -relativeseconds N] [-mine=false] [-coinselect STRATEGY] [-feerate RATE] [-replaceable]")
+	fmt.Println("    STRATEGY is bnb (default), largest, smallest, random or first, RATE is the fee per 1000 bytes")
+	fmt.Println("    -replaceable lets bumpfee replace the transaction while it is pending")
+	fmt.Println("  sendmany -from FROM [-to ADDRESS:AMOUNT ...] [-file PAYMENTS] -stake STAKE - Pay many recipients in one transaction")
+	fmt.Println("    PAYMENTS is a CSV file of address,amount lines or a JSON array of {\"address\", \"amount\"} [-locktime HEIGHT|TIME] [-mine=false]")
+	fmt.Println("    [-coinselect STRATEGY] [-feerate RATE] [-replaceable]")
+	fmt.Println("  issueasset -from FROM -name NAME -supply SUPPLY [-metadata TEXT] [-to ADDRESS] -stake STAKE - Issue a new asset")
+	fmt.Println("    [-mine=false] [-feerate RATE]")
+	fmt.Println("  getassetbalance -address ADDRESS - Get the asset balances of ADDRESS")
+	fmt.Println("  mintnft -from FROM -uri URI (-metadata FILE | -metadatahash HASH) [-to ADDRESS] -stake STAKE - Mint an NFT issued by FROM")
+	fmt.Println("    [-mine=false] [-feerate RATE]")
+	fmt.Println("  transfernft -from FROM -to TO -id NFT -stake STAKE [-mine=false] [-feerate RATE] - Transfer an NFT")
+	fmt.Println("  listnfts (-address ADDRESS | -id NFT) - List the NFTs of ADDRESS or print the current owner of an NFT")
+	fmt.Println("  spvsync -node PATH - Validate and store the block headers of the full node database at PATH in headers.db")
+	fmt.Println("  spvbalance -address ADDRESS -node PATH - Get the balance of ADDRESS proven against the stored headers")
+	fmt.Println("  spvscan -address ADDRESS -node PATH - Find the transactions of ADDRESS through the block filters of the node")
+	fmt.Println("  getblockfilter -hash BLOCK - Print the compact filter and filter header of a block")
+	fmt.Println("  reindexfilters - Rebuilds the block filter index")
+	fmt.Println("  openchannel -from FROM -to TO -amount AMOUNT -stake STAKE [-mine=false] - Open a payment channel funded by FROM")
+	fmt.Println("  channelpay -id CHANNEL -from FROM -amount AMOUNT - Pay AMOUNT to the other party of a channel off chain")
+	fmt.Println("  closechannel -id CHANNEL [-force ADDRESS] -stake STAKE [-mine=false] - Close a channel cooperatively,")
+	fmt.Println("    or alone with the latest commitment of ADDRESS")
+	fmt.Println("  sweepchannel -id CHANNEL -address ADDRESS -stake STAKE [-mine=false] - Claim the delayed balance of ADDRESS")
+	fmt.Println("    from a closed channel, or all of it when the other party closed with a revoked commitment")
+	fmt.Println("  listchannels - List the payment channels of the wallet file")
+	fmt.Println("  deploycontract -from FROM -code FILE -gaslimit GAS [-gasprice PRICE] -stake STAKE - Deploy the contract assembly in FILE")
+	fmt.Println("    [-mine=false] [-feerate RATE]")
+	fmt.Println("  callcontract -from FROM -contract ID [-args N,N,...] -gaslimit GAS [-gasprice PRICE] -stake STAKE - Call a contract")
+	fmt.Println("    [-mine=false] [-feerate RATE]")
+	fmt.Println("  getcontract -id ID - Print the code size and storage of a contract")
+	fmt.Println("  disconnectblock - Remove the tip block and return its transactions to the mempool")
+	fmt.Println("  proposeparam -from FROM -param NAME -value VALUE [-activation HEIGHT] -stake STAKE - Propose changing a chain parameter")
+	fmt.Println("    [-mine=false] [-feerate RATE]")
+	fmt.Println("  voteparam -from FROM -proposal ID [-approve=false] -stake STAKE - Vote on a proposal with the balance of FROM")
+	fmt.Println("    [-mine=false] [-feerate RATE]")
+	fmt.Println("  listproposals - List the parameter change proposals and their outcome")
+	fmt.Println("  getparams [-height HEIGHT] - Print the chain parameters in effect at HEIGHT, the next block by default")
+	fmt.Println("  claimname -from FROM -name NAME -stake STAKE [-mine=false] [-feerate RATE] - Register an unregistered or expired name to FROM")
+	fmt.Println("  renewname -from FROM -name NAME -stake STAKE [-mine=false] [-feerate RATE] - Extend the registration of a name FROM owns")
+	fmt.Println("  transfername -from FROM -name NAME -to ADDRESS -stake STAKE [-mine=false] [-feerate RATE] - Hand a name FROM owns to ADDRESS")
+	fmt.Println("  resolvename -name NAME - Print the address a name is registered to")
+	fmt.Println("  encryptwallet [-passphrase PASSPHRASE] - Encrypt the private keys of the wallet file, the passphrase is read")
+	fmt.Println("    from stdin when not given")
+	fmt.Println("  walletpassphrase [-passphrase PASSPHRASE] -timeout SECONDS - Unlock an encrypted wallet file for SECONDS")
+	fmt.Println("  walletlock - Lock an unlocked wallet file again")
+	fmt.Println("  restorewallet -mnemonic MNEMONIC [-gaplimit N] - Restore an HD seed and the addresses of it used on the chain")
+	fmt.Println("  dumpprivkey -address ADDRESS - Print the private key of a wallet ADDRESS in the checksummed export format")
+	fmt.Println("  importprivkey -key KEY [-rescan] - Add an exported private key to the wallet file, -rescan finds its past outputs")
+	fmt.Println("  dumpwallet -file FILE - Write all private keys of the wallet file to FILE")
+	fmt.Println("  importwallet -file FILE [-rescan] - Add the private keys of a dumpwallet FILE to the wallet file")
+	fmt.Println("  bumpfee -txid TXID [-feerate RATE] - Replace a pending replaceable transaction with one paying a higher fee")
+	fmt.Println("  cpfp -txid TXID -feerate RATE [-to ADDRESS] - Speed up a pending incoming transaction with a child paying for both")
+	fmt.Println("  sendrawtransaction -tx TX - Add a hex encoded signed or fully signed partial transaction to the mempool")
+	fmt.Println("  createrawtransaction -from FROM [-to ADDRESS:AMOUNT ...] [-file PAYMENTS] - Build an unsigned partial transaction")
+	fmt.Println("    without the keys of FROM [-locktime HEIGHT|TIME] [-coinselect STRATEGY] [-feerate RATE]")
+	fmt.Println("  signrawtransaction -tx TX [-sighash TYPE] - Sign a partial transaction with the wallet file only, for offline signing")
+	fmt.Println("  combinerawtransaction -txs TX1,TX2,... - Merge the signatures of copies of a partial transaction")
+	fmt.Println("  initiateswap -from FROM -to TO -amount AMOUNT -locktime HEIGHT|TIME [-secrethash HASH] -stake STAKE")
+	fmt.Println("    - Lock AMOUNT in a hash time-locked contract for TO, refundable to FROM after the lock time")
+	fmt.Println("  redeemswap -contract CONTRACT -secret SECRET -stake STAKE - Claim a swap contract with its secret")
+	fmt.Println("  refundswap -contract CONTRACT -stake STAKE - Take back the coins of an expired swap contract")
+	fmt.Println("  auditswap -contract CONTRACT - Print the terms and funding of a swap contract")
+	fmt.Println("  extractsecret -contract CONTRACT - Print the secret revealed by the redemption of a swap contract")
+	fmt.Println("  signmultisig -tx TX -stake STAKE - Add the wallet's cosigner signatures to TX and mine it once complete")
 }
 
 func (cli *CLI) validateArgs() {
@@ -39,13 +115,247 @@ func (cli *CLI) Run() {
 	printChainCmd := flag.NewFlagSet("printchain", flag.ExitOnError)
 	reindexUTXOCmd := flag.NewFlagSet("reindexutxo", flag.ExitOnError)
 	sendCmd := flag.NewFlagSet("send", flag.ExitOnError)
+	sendManyCmd := flag.NewFlagSet("sendmany", flag.ExitOnError)
+	issueAssetCmd := flag.NewFlagSet("issueasset", flag.ExitOnError)
+	getAssetBalanceCmd := flag.NewFlagSet("getassetbalance", flag.ExitOnError)
+	mintNFTCmd := flag.NewFlagSet("mintnft", flag.ExitOnError)
+	transferNFTCmd := flag.NewFlagSet("transfernft", flag.ExitOnError)
+	listNFTsCmd := flag.NewFlagSet("listnfts", flag.ExitOnError)
+	spvSyncCmd := flag.NewFlagSet("spvsync", flag.ExitOnError)
+	spvBalanceCmd := flag.NewFlagSet("spvbalance", flag.ExitOnError)
+	spvScanCmd := flag.NewFlagSet("spvscan", flag.ExitOnError)
+	getBlockFilterCmd := flag.NewFlagSet("getblockfilter", flag.ExitOnError)
+	reindexFiltersCmd := flag.NewFlagSet("reindexfilters", flag.ExitOnError)
+	openChannelCmd := flag.NewFlagSet("openchannel", flag.ExitOnError)
+	channelPayCmd := flag.NewFlagSet("channelpay", flag.ExitOnError)
+	closeChannelCmd := flag.NewFlagSet("closechannel", flag.ExitOnError)
+	sweepChannelCmd := flag.NewFlagSet("sweepchannel", flag.ExitOnError)
+	listChannelsCmd := flag.NewFlagSet("listchannels", flag.ExitOnError)
+	deployContractCmd := flag.NewFlagSet("deploycontract", flag.ExitOnError)
+	callContractCmd := flag.NewFlagSet("callcontract", flag.ExitOnError)
+	getContractCmd := flag.NewFlagSet("getcontract", flag.ExitOnError)
+	disconnectBlockCmd := flag.NewFlagSet("disconnectblock", flag.ExitOnError)
+	proposeParamCmd := flag.NewFlagSet("proposeparam", flag.ExitOnError)
+	voteParamCmd := flag.NewFlagSet("voteparam", flag.ExitOnError)
+	listProposalsCmd := flag.NewFlagSet("listproposals", flag.ExitOnError)
+	getParamsCmd := flag.NewFlagSet("getparams", flag.ExitOnError)
+	claimNameCmd := flag.NewFlagSet("claimname", flag.ExitOnError)
+	renewNameCmd := flag.NewFlagSet("renewname", flag.ExitOnError)
+	transferNameCmd := flag.NewFlagSet("transfername", flag.ExitOnError)
+	resolveNameCmd := flag.NewFlagSet("resolvename", flag.ExitOnError)
+	encryptWalletCmd := flag.NewFlagSet("encryptwallet", flag.ExitOnError)
+	walletPassphraseCmd := flag.NewFlagSet("walletpassphrase", flag.ExitOnError)
+	walletLockCmd := flag.NewFlagSet("walletlock", flag.ExitOnError)
+	restoreWalletCmd := flag.NewFlagSet("restorewallet", flag.ExitOnError)
+	dumpPrivKeyCmd := flag.NewFlagSet("dumpprivkey", flag.ExitOnError)
+	importPrivKeyCmd := flag.NewFlagSet("importprivkey", flag.ExitOnError)
+	dumpWalletCmd := flag.NewFlagSet("dumpwallet", flag.ExitOnError)
+	importWalletCmd := flag.NewFlagSet("importwallet", flag.ExitOnError)
+	bumpFeeCmd := flag.NewFlagSet("bumpfee", flag.ExitOnError)
+	cpfpCmd := flag.NewFlagSet("cpfp", flag.ExitOnError)
+	createMultiSigCmd := flag.NewFlagSet("createmultisig", flag.ExitOnError)
+	addMultiSigAddressCmd := flag.NewFlagSet("addmultisigaddress", flag.ExitOnError)
+	createSchnorrAddressCmd := flag.NewFlagSet("createschnorraddress", flag.ExitOnError)
+	signMultiSigCmd := flag.NewFlagSet("signmultisig", flag.ExitOnError)
+	getPubKeyCmd := flag.NewFlagSet("getpubkey", flag.ExitOnError)
+	sendRawTransactionCmd := flag.NewFlagSet("sendrawtransaction", flag.ExitOnError)
+	createRawTransactionCmd := flag.NewFlagSet("createrawtransaction", flag.ExitOnError)
+	signRawTransactionCmd := flag.NewFlagSet("signrawtransaction", flag.ExitOnError)
+	combineRawTransactionCmd := flag.NewFlagSet("combinerawtransaction", flag.ExitOnError)
+	mineCmd := flag.NewFlagSet("mine", flag.ExitOnError)
+	migrateWalletCmd := flag.NewFlagSet("migratewallet", flag.ExitOnError)
+	initiateSwapCmd := flag.NewFlagSet("initiateswap", flag.ExitOnError)
+	redeemSwapCmd := flag.NewFlagSet("redeemswap", flag.ExitOnError)
+	refundSwapCmd := flag.NewFlagSet("refundswap", flag.ExitOnError)
+	auditSwapCmd := flag.NewFlagSet("auditswap", flag.ExitOnError)
+	extractSecretCmd := flag.NewFlagSet("extractsecret", flag.ExitOnError)
+	notarizeCmd := flag.NewFlagSet("notarize", flag.ExitOnError)
+	verifyNotarizationCmd := flag.NewFlagSet("verifynotarization", flag.ExitOnError)
+	crowdfundCmd := flag.NewFlagSet("crowdfund", flag.ExitOnError)
+	contributeCmd := flag.NewFlagSet("contribute", flag.ExitOnError)
 
 	getBalanceAddress := getBalanceCmd.String("address", "", "The address to get balance for")
 	createBlockchainAddress := createBlockchainCmd.String("address", "", "The address to send genesis block reward to")
+	createWalletHD := createWalletCmd.Bool("hd", false, "Derive the key from the HD seed of the wallet file")
+	createWalletChange := createWalletCmd.Bool("change", false, "Derive the key from the HD change chain")
 	sendFrom := sendCmd.String("from", "", "Source wallet address")
-	sendTo := sendCmd.String("to", "", "Destination wallet address")
+	sendTo := sendCmd.String("to", "", "Destination wallet address or registered name")
 	sendAmount := sendCmd.Int("amount", 0, "Amount to send")
+	sendAsset := sendCmd.String("asset", "", "Hex ID of the asset to send instead of coins")
 	stake := sendCmd.Uint64("stake", 0, "Stake weight")
+	sendLockTime := sendCmd.Int64("locktime", 0, "Block height, or unix time if at least 500000000, the transaction is locked until")
+	sendRelativeBlocks := sendCmd.Int("relativeblocks", 0, "Confirmations the spent outputs need before the transaction is valid")
+	sendRelativeSeconds := sendCmd.Int64("relativeseconds", 0, "Seconds since the spent outputs confirmed before the transaction is valid")
+	sendMine := sendCmd.Bool("mine", true, "Mine the transaction immediately instead of leaving it in the mempool")
+	sendCoinSelect := sendCmd.String("coinselect", "bnb", "Coin selection strategy: bnb, largest, smallest, random or first")
+	sendFeeRate := sendCmd.Int("feerate", 0, "Fee per 1000 bytes of transaction")
+	sendReplaceable := sendCmd.Bool("replaceable", false, "Allow replacing the pending transaction with one paying a higher fee")
+	var sendManyPayments PaymentList
+	sendManyCmd.Var(&sendManyPayments, "to", "Recipient as ADDRESS:AMOUNT, may be repeated")
+	sendManyFrom := sendManyCmd.String("from", "", "Source wallet address")
+	sendManyFile := sendManyCmd.String("file", "", "CSV or JSON file of recipients")
+	sendManyStake := sendManyCmd.Uint64("stake", 0, "Stake weight")
+	sendManyLockTime := sendManyCmd.Int64("locktime", 0, "Block height, or unix time if at least 500000000, the transaction is locked until")
+	sendManyMine := sendManyCmd.Bool("mine", true, "Mine the transaction immediately instead of leaving it in the mempool")
+	sendManyCoinSelect := sendManyCmd.String("coinselect", "bnb", "Coin selection strategy: bnb, largest, smallest, random or first")
+	sendManyFeeRate := sendManyCmd.Int("feerate", 0, "Fee per 1000 bytes of transaction")
+	sendManyReplaceable := sendManyCmd.Bool("replaceable", false, "Allow replacing the pending transaction with one paying a higher fee")
+	issueAssetFrom := issueAssetCmd.String("from", "", "Issuing wallet address paying the fee")
+	issueAssetTo := issueAssetCmd.String("to", "", "Address receiving the supply, the issuer by default")
+	issueAssetName := issueAssetCmd.String("name", "", "Name of the asset")
+	issueAssetSupply := issueAssetCmd.Int("supply", 0, "Number of units issued")
+	issueAssetMetadata := issueAssetCmd.String("metadata", "", "Free-form description stored with the issuance")
+	issueAssetStake := issueAssetCmd.Uint64("stake", 0, "Stake weight")
+	issueAssetMine := issueAssetCmd.Bool("mine", true, "Mine the transaction immediately instead of leaving it in the mempool")
+	issueAssetFeeRate := issueAssetCmd.Int("feerate", 0, "Fee per 1000 bytes of transaction")
+	getAssetBalanceAddress := getAssetBalanceCmd.String("address", "", "The address to get the asset balances for")
+	mintNFTFrom := mintNFTCmd.String("from", "", "Issuing wallet address paying the fee")
+	mintNFTTo := mintNFTCmd.String("to", "", "Address receiving the NFT, the issuer by default")
+	mintNFTURI := mintNFTCmd.String("uri", "", "URI of the NFT metadata")
+	mintNFTMetadata := mintNFTCmd.String("metadata", "", "File whose SHA-256 hash is stored as the metadata hash")
+	mintNFTMetadataHash := mintNFTCmd.String("metadatahash", "", "Hex SHA-256 hash of the metadata")
+	mintNFTStake := mintNFTCmd.Uint64("stake", 0, "Stake weight")
+	mintNFTMine := mintNFTCmd.Bool("mine", true, "Mine the transaction immediately instead of leaving it in the mempool")
+	mintNFTFeeRate := mintNFTCmd.Int("feerate", 0, "Fee per 1000 bytes of transaction")
+	transferNFTFrom := transferNFTCmd.String("from", "", "Wallet address holding the NFT")
+	transferNFTTo := transferNFTCmd.String("to", "", "Address receiving the NFT")
+	transferNFTID := transferNFTCmd.String("id", "", "Hex ID of the NFT")
+	transferNFTStake := transferNFTCmd.Uint64("stake", 0, "Stake weight")
+	transferNFTMine := transferNFTCmd.Bool("mine", true, "Mine the transaction immediately instead of leaving it in the mempool")
+	transferNFTFeeRate := transferNFTCmd.Int("feerate", 0, "Fee per 1000 bytes of transaction")
+	listNFTsAddress := listNFTsCmd.String("address", "", "The address to list the NFTs of")
+	listNFTsID := listNFTsCmd.String("id", "", "Hex ID of the NFT to find the owner of")
+	spvSyncNode := spvSyncCmd.String("node", "", "Path of the full node blockchain database")
+	spvBalanceAddress := spvBalanceCmd.String("address", "", "The address to get balance for")
+	spvBalanceNode := spvBalanceCmd.String("node", "", "Path of the full node blockchain database")
+	spvScanAddress := spvScanCmd.String("address", "", "The address to find transactions for")
+	spvScanNode := spvScanCmd.String("node", "", "Path of the full node blockchain database")
+	getBlockFilterHash := getBlockFilterCmd.String("hash", "", "Hash of the block")
+	openChannelFrom := openChannelCmd.String("from", "", "Wallet address funding the channel")
+	openChannelTo := openChannelCmd.String("to", "", "Wallet address of the other party")
+	openChannelAmount := openChannelCmd.Int("amount", 0, "Capacity of the channel")
+	openChannelStake := openChannelCmd.Uint64("stake", 0, "Stake weight")
+	openChannelMine := openChannelCmd.Bool("mine", true, "Mine the transaction immediately instead of leaving it in the mempool")
+	channelPayID := channelPayCmd.String("id", "", "ID of the channel")
+	channelPayFrom := channelPayCmd.String("from", "", "Address of the paying party")
+	channelPayAmount := channelPayCmd.Int("amount", 0, "Amount to pay")
+	closeChannelID := closeChannelCmd.String("id", "", "ID of the channel")
+	closeChannelForce := closeChannelCmd.String("force", "", "Address of the party closing alone")
+	closeChannelStake := closeChannelCmd.Uint64("stake", 0, "Stake weight")
+	closeChannelMine := closeChannelCmd.Bool("mine", true, "Mine the transaction immediately instead of leaving it in the mempool")
+	sweepChannelID := sweepChannelCmd.String("id", "", "ID of the channel")
+	sweepChannelAddress := sweepChannelCmd.String("address", "", "Address of the claiming party")
+	sweepChannelStake := sweepChannelCmd.Uint64("stake", 0, "Stake weight")
+	sweepChannelMine := sweepChannelCmd.Bool("mine", true, "Mine the transaction immediately instead of leaving it in the mempool")
+	deployContractFrom := deployContractCmd.String("from", "", "Wallet address paying for the deployment")
+	deployContractCode := deployContractCmd.String("code", "", "File with the contract assembly")
+	deployContractGasLimit := deployContractCmd.Int("gaslimit", 0, "Most gas the deployment may use")
+	deployContractGasPrice := deployContractCmd.Int("gasprice", 1, "Fee paid per unit of gas")
+	deployContractStake := deployContractCmd.Uint64("stake", 0, "Stake weight")
+	deployContractMine := deployContractCmd.Bool("mine", true, "Mine the transaction immediately instead of leaving it in the mempool")
+	deployContractFeeRate := deployContractCmd.Int("feerate", 0, "Fee per 1000 bytes of transaction")
+	callContractFrom := callContractCmd.String("from", "", "Wallet address paying for the call")
+	callContractID := callContractCmd.String("contract", "", "Hex ID of the contract")
+	callContractArgs := callContractCmd.String("args", "", "Comma separated integer arguments")
+	callContractGasLimit := callContractCmd.Int("gaslimit", 0, "Most gas the call may use")
+	callContractGasPrice := callContractCmd.Int("gasprice", 1, "Fee paid per unit of gas")
+	callContractStake := callContractCmd.Uint64("stake", 0, "Stake weight")
+	callContractMine := callContractCmd.Bool("mine", true, "Mine the transaction immediately instead of leaving it in the mempool")
+	callContractFeeRate := callContractCmd.Int("feerate", 0, "Fee per 1000 bytes of transaction")
+	getContractID := getContractCmd.String("id", "", "Hex ID of the contract")
+	proposeParamFrom := proposeParamCmd.String("from", "", "Wallet address making the proposal")
+	proposeParamName := proposeParamCmd.String("param", "", "Name of the chain parameter")
+	proposeParamValue := proposeParamCmd.Int("value", 0, "Proposed value")
+	proposeParamActivation := proposeParamCmd.Int("activation", 0, "Height the change activates at, the earliest possible by default")
+	proposeParamStake := proposeParamCmd.Uint64("stake", 0, "Stake weight")
+	proposeParamMine := proposeParamCmd.Bool("mine", true, "Mine the transaction immediately instead of leaving it in the mempool")
+	proposeParamFeeRate := proposeParamCmd.Int("feerate", 0, "Fee per 1000 bytes of transaction")
+	voteParamFrom := voteParamCmd.String("from", "", "Wallet address voting with its balance")
+	voteParamProposal := voteParamCmd.String("proposal", "", "Hex ID of the proposal")
+	voteParamApprove := voteParamCmd.Bool("approve", true, "Vote for the proposal, false votes against it")
+	voteParamStake := voteParamCmd.Uint64("stake", 0, "Stake weight")
+	voteParamMine := voteParamCmd.Bool("mine", true, "Mine the transaction immediately instead of leaving it in the mempool")
+	voteParamFeeRate := voteParamCmd.Int("feerate", 0, "Fee per 1000 bytes of transaction")
+	getParamsHeight := getParamsCmd.Int("height", -1, "Block height, the next block by default")
+	claimNameFrom := claimNameCmd.String("from", "", "Wallet address registering the name")
+	claimNameName := claimNameCmd.String("name", "", "Name to register")
+	claimNameStake := claimNameCmd.Uint64("stake", 0, "Stake weight")
+	claimNameMine := claimNameCmd.Bool("mine", true, "Mine the transaction immediately instead of leaving it in the mempool")
+	claimNameFeeRate := claimNameCmd.Int("feerate", 0, "Fee per 1000 bytes of transaction")
+	renewNameFrom := renewNameCmd.String("from", "", "Wallet address owning the name")
+	renewNameName := renewNameCmd.String("name", "", "Name to renew")
+	renewNameStake := renewNameCmd.Uint64("stake", 0, "Stake weight")
+	renewNameMine := renewNameCmd.Bool("mine", true, "Mine the transaction immediately instead of leaving it in the mempool")
+	renewNameFeeRate := renewNameCmd.Int("feerate", 0, "Fee per 1000 bytes of transaction")
+	transferNameFrom := transferNameCmd.String("from", "", "Wallet address owning the name")
+	transferNameName := transferNameCmd.String("name", "", "Name to transfer")
+	transferNameTo := transferNameCmd.String("to", "", "Wallet address of the new owner")
+	transferNameStake := transferNameCmd.Uint64("stake", 0, "Stake weight")
+	transferNameMine := transferNameCmd.Bool("mine", true, "Mine the transaction immediately instead of leaving it in the mempool")
+	transferNameFeeRate := transferNameCmd.Int("feerate", 0, "Fee per 1000 bytes of transaction")
+	resolveNameName := resolveNameCmd.String("name", "", "Name to look up")
+	encryptWalletPassphrase := encryptWalletCmd.String("passphrase", "", "Passphrase to encrypt the wallet file with")
+	walletPassphrasePassphrase := walletPassphraseCmd.String("passphrase", "", "Passphrase of the wallet file")
+	walletPassphraseTimeout := walletPassphraseCmd.Int("timeout", 0, "Seconds the wallet file stays unlocked")
+	restoreWalletMnemonic := restoreWalletCmd.String("mnemonic", "", "Mnemonic of the HD seed")
+	restoreWalletGapLimit := restoreWalletCmd.Int("gaplimit", hdGapLimit, "Unused addresses in a row to look past the last used one")
+	dumpPrivKeyAddress := dumpPrivKeyCmd.String("address", "", "The wallet address to print the private key of")
+	importPrivKeyKey := importPrivKeyCmd.String("key", "", "Exported private key")
+	importPrivKeyRescan := importPrivKeyCmd.Bool("rescan", false, "Scan the chain for the outputs paid to the key")
+	dumpWalletFile := dumpWalletCmd.String("file", "", "File to write the keys to")
+	importWalletFile := importWalletCmd.String("file", "", "File written by dumpwallet")
+	importWalletRescan := importWalletCmd.Bool("rescan", false, "Scan the chain for the outputs paid to the keys")
+	bumpFeeTxID := bumpFeeCmd.String("txid", "", "ID of the pending transaction to replace")
+	bumpFeeRate := bumpFeeCmd.Int("feerate", 0, "Fee per 1000 bytes the replacement pays at least")
+	cpfpTxID := cpfpCmd.String("txid", "", "ID of the pending transaction paying the wallet")
+	cpfpFeeRate := cpfpCmd.Int("feerate", 0, "Fee per 1000 bytes the parent and child pay together")
+	cpfpTo := cpfpCmd.String("to", "", "Address receiving the child output, the first spent key by default")
+	createMultiSigRequired := createMultiSigCmd.Int("required", 0, "Number of signatures required")
+	createMultiSigKeys := createMultiSigCmd.String("keys", "", "Comma separated wallet addresses or hex public keys")
+	addMultiSigRequired := addMultiSigAddressCmd.Int("required", 0, "Number of signatures required")
+	addMultiSigKeys := addMultiSigAddressCmd.String("keys", "", "Comma separated wallet addresses or hex public keys")
+	createSchnorrKeys := createSchnorrAddressCmd.String("keys", "", "Comma separated wallet addresses or hex compressed public keys")
+	signMultiSigTx := signMultiSigCmd.String("tx", "", "Hex encoded partially signed transaction")
+	signMultiSigStake := signMultiSigCmd.Uint64("stake", 0, "Stake weight")
+	getPubKeyAddress := getPubKeyCmd.String("address", "", "The wallet address to print the public key of")
+	sendRawTransactionTx := sendRawTransactionCmd.String("tx", "", "Hex encoded signed transaction")
+	var createRawPayments PaymentList
+	createRawTransactionCmd.Var(&createRawPayments, "to", "Recipient as ADDRESS:AMOUNT, may be repeated")
+	createRawFrom := createRawTransactionCmd.String("from", "", "Address whose coins are spent")
+	createRawFile := createRawTransactionCmd.String("file", "", "CSV or JSON file of recipients")
+	createRawLockTime := createRawTransactionCmd.Int64("locktime", 0, "Block height, or unix time if at least 500000000, the transaction is locked until")
+	createRawCoinSelect := createRawTransactionCmd.String("coinselect", "bnb", "Coin selection strategy: bnb, largest, smallest, random or first")
+	createRawFeeRate := createRawTransactionCmd.Int("feerate", 0, "Fee per 1000 bytes of transaction")
+	signRawTx := signRawTransactionCmd.String("tx", "", "Hex encoded partial transaction")
+	signRawSigHash := signRawTransactionCmd.String("sighash", "ALL", "Signature hash type")
+	combineRawTxs := combineRawTransactionCmd.String("txs", "", "Comma separated hex encoded partial transactions")
+	mineAddress := mineCmd.String("address", "", "The address to send the block reward to")
+	mineStake := mineCmd.Uint64("stake", 0, "Stake weight")
+	migrateWalletSweep := migrateWalletCmd.Bool("sweep", false, "Send the coins of legacy addresses to their migrated addresses")
+	migrateWalletStake := migrateWalletCmd.Uint64("stake", 0, "Stake weight")
+	initiateSwapFrom := initiateSwapCmd.String("from", "", "Address funding the contract and receiving the refund")
+	initiateSwapTo := initiateSwapCmd.String("to", "", "Address that can redeem the contract with the secret")
+	initiateSwapAmount := initiateSwapCmd.Int("amount", 0, "Amount to lock in the contract")
+	initiateSwapLockTime := initiateSwapCmd.Int64("locktime", 0, "Block height, or unix time if at least 500000000, after which the refund is possible")
+	initiateSwapSecretHash := initiateSwapCmd.String("secrethash", "", "Secret hash of the counterparty's contract, a new secret is made up if empty")
+	initiateSwapStake := initiateSwapCmd.Uint64("stake", 0, "Stake weight")
+	redeemSwapContract := redeemSwapCmd.String("contract", "", "Hex encoded swap contract")
+	redeemSwapSecret := redeemSwapCmd.String("secret", "", "Hex encoded secret")
+	redeemSwapStake := redeemSwapCmd.Uint64("stake", 0, "Stake weight")
+	refundSwapContract := refundSwapCmd.String("contract", "", "Hex encoded swap contract")
+	refundSwapStake := refundSwapCmd.Uint64("stake", 0, "Stake weight")
+	auditSwapContract := auditSwapCmd.String("contract", "", "Hex encoded swap contract")
+	extractSecretContract := extractSecretCmd.String("contract", "", "Hex encoded swap contract")
+	notarizeFile := notarizeCmd.String("file", "", "The document to notarize")
+	notarizeFrom := notarizeCmd.String("from", "", "Address paying for the notarization transaction")
+	notarizeStake := notarizeCmd.Uint64("stake", 0, "Stake weight")
+	verifyNotarizationFile := verifyNotarizationCmd.String("file", "", "The document to look up")
+	crowdfundTo := crowdfundCmd.String("to", "", "Address receiving the funds")
+	crowdfundGoal := crowdfundCmd.Int("goal", 0, "Amount to raise")
+	contributeTx := contributeCmd.String("tx", "", "Hex encoded crowdfunding transaction")
+	contributeFrom := contributeCmd.String("from", "", "Contributor wallet address")
+	contributeAmount := contributeCmd.Int("amount", 0, "Amount to contribute")
+	contributeSigHash := contributeCmd.String("sighash", "ALL|ANYONECANPAY", "Signature hash type of the added inputs")
 
 	switch os.Args[1] {
 	case "getbalance":
@@ -78,11 +388,305 @@ func (cli *CLI) Run() {
 		if err != nil {
 			log.Panic(err)
 		}
+	case "sendmany":
+		err := sendManyCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "issueasset":
+		err := issueAssetCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "getassetbalance":
+		err := getAssetBalanceCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "mintnft":
+		err := mintNFTCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "transfernft":
+		err := transferNFTCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "listnfts":
+		err := listNFTsCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "spvsync":
+		err := spvSyncCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "spvbalance":
+		err := spvBalanceCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "spvscan":
+		err := spvScanCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "getblockfilter":
+		err := getBlockFilterCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "reindexfilters":
+		err := reindexFiltersCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "openchannel":
+		err := openChannelCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "channelpay":
+		err := channelPayCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "closechannel":
+		err := closeChannelCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "sweepchannel":
+		err := sweepChannelCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "listchannels":
+		err := listChannelsCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "deploycontract":
+		err := deployContractCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "callcontract":
+		err := callContractCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "getcontract":
+		err := getContractCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "disconnectblock":
+		err := disconnectBlockCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "proposeparam":
+		err := proposeParamCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "voteparam":
+		err := voteParamCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "listproposals":
+		err := listProposalsCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "getparams":
+		err := getParamsCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "claimname":
+		err := claimNameCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "renewname":
+		err := renewNameCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "transfername":
+		err := transferNameCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "resolvename":
+		err := resolveNameCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "encryptwallet":
+		err := encryptWalletCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "walletpassphrase":
+		err := walletPassphraseCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "walletlock":
+		err := walletLockCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case walletAgentCommand:
+		// Started by walletpassphrase, not listed in the usage
+		cli.walletAgent()
+		return
+	case "restorewallet":
+		err := restoreWalletCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "dumpprivkey":
+		err := dumpPrivKeyCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "importprivkey":
+		err := importPrivKeyCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "dumpwallet":
+		err := dumpWalletCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "importwallet":
+		err := importWalletCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "bumpfee":
+		err := bumpFeeCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "cpfp":
+		err := cpfpCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
 	case "reindexutxo":
 		err := reindexUTXOCmd.Parse(os.Args[2:])
 		if err != nil {
 			log.Panic(err)
 		}
+	case "createmultisig":
+		err := createMultiSigCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "addmultisigaddress":
+		err := addMultiSigAddressCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "createschnorraddress":
+		err := createSchnorrAddressCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "signmultisig":
+		err := signMultiSigCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "getpubkey":
+		err := getPubKeyCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "createrawtransaction":
+		err := createRawTransactionCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "signrawtransaction":
+		err := signRawTransactionCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "combinerawtransaction":
+		err := combineRawTransactionCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "sendrawtransaction":
+		err := sendRawTransactionCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "migratewallet":
+		err := migrateWalletCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "mine":
+		err := mineCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "initiateswap":
+		err := initiateSwapCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "redeemswap":
+		err := redeemSwapCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "refundswap":
+		err := refundSwapCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "auditswap":
+		err := auditSwapCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "extractsecret":
+		err := extractSecretCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "notarize":
+		err := notarizeCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "verifynotarization":
+		err := verifyNotarizationCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "crowdfund":
+		err := crowdfundCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "contribute":
+		err := contributeCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
 	default:
 		cli.printUsage()
 		os.Exit(1)
@@ -105,7 +709,11 @@ func (cli *CLI) Run() {
 	}
 
 	if createWalletCmd.Parsed() {
-		cli.createWallet()
+		if *createWalletChange && !*createWalletHD {
+			createWalletCmd.Usage()
+			os.Exit(1)
+		}
+		cli.createWallet(*createWalletHD, *createWalletChange)
 	}
 
 	if listAddressesCmd.Parsed() {
@@ -126,6 +734,456 @@ func (cli *CLI) Run() {
 			os.Exit(1)
 		}
 
-		cli.send(*sendFrom, *sendTo, *sendAmount, int64(*stake))
+		if *sendRelativeBlocks != 0 && *sendRelativeSeconds != 0 {
+			sendCmd.Usage()
+			os.Exit(1)
+		}
+
+		sequence := RelativeLockBlocks(*sendRelativeBlocks)
+		if *sendRelativeSeconds != 0 {
+			sequence = RelativeLockSeconds(*sendRelativeSeconds)
+		}
+		if *sendReplaceable {
+			sequence |= sequenceReplaceable
+		}
+
+		cli.send(*sendFrom, *sendTo, *sendAmount, *sendAsset, int64(*stake), *sendLockTime, sequence, *sendMine, *sendCoinSelect, *sendFeeRate)
+	}
+
+	if sendManyCmd.Parsed() {
+		if *sendManyFrom == "" || (len(sendManyPayments) == 0 && *sendManyFile == "") {
+			sendManyCmd.Usage()
+			os.Exit(1)
+		}
+
+		var sequence uint32
+		if *sendManyReplaceable {
+			sequence = sequenceReplaceable
+		}
+		cli.sendMany(*sendManyFrom, sendManyPayments, *sendManyFile, int64(*sendManyStake), *sendManyLockTime, sequence, *sendManyMine, *sendManyCoinSelect, *sendManyFeeRate)
+	}
+
+	if issueAssetCmd.Parsed() {
+		if *issueAssetFrom == "" || *issueAssetName == "" || *issueAssetSupply <= 0 {
+			issueAssetCmd.Usage()
+			os.Exit(1)
+		}
+		cli.issueAsset(*issueAssetFrom, *issueAssetTo, *issueAssetName, *issueAssetSupply, *issueAssetMetadata, int64(*issueAssetStake), *issueAssetMine, *issueAssetFeeRate)
+	}
+
+	if getAssetBalanceCmd.Parsed() {
+		if *getAssetBalanceAddress == "" {
+			getAssetBalanceCmd.Usage()
+			os.Exit(1)
+		}
+		cli.getAssetBalance(*getAssetBalanceAddress)
+	}
+
+	if mintNFTCmd.Parsed() {
+		if *mintNFTFrom == "" || *mintNFTURI == "" || (*mintNFTMetadata == "") == (*mintNFTMetadataHash == "") {
+			mintNFTCmd.Usage()
+			os.Exit(1)
+		}
+		cli.mintNFT(*mintNFTFrom, *mintNFTTo, *mintNFTURI, *mintNFTMetadata, *mintNFTMetadataHash, int64(*mintNFTStake), *mintNFTMine, *mintNFTFeeRate)
+	}
+
+	if transferNFTCmd.Parsed() {
+		if *transferNFTFrom == "" || *transferNFTTo == "" || *transferNFTID == "" {
+			transferNFTCmd.Usage()
+			os.Exit(1)
+		}
+		cli.transferNFT(*transferNFTFrom, *transferNFTTo, *transferNFTID, int64(*transferNFTStake), *transferNFTMine, *transferNFTFeeRate)
+	}
+
+	if listNFTsCmd.Parsed() {
+		if (*listNFTsAddress == "") == (*listNFTsID == "") {
+			listNFTsCmd.Usage()
+			os.Exit(1)
+		}
+		cli.listNFTs(*listNFTsAddress, *listNFTsID)
+	}
+
+	if spvSyncCmd.Parsed() {
+		if *spvSyncNode == "" {
+			spvSyncCmd.Usage()
+			os.Exit(1)
+		}
+		cli.spvSync(*spvSyncNode)
+	}
+
+	if spvBalanceCmd.Parsed() {
+		if *spvBalanceAddress == "" || *spvBalanceNode == "" {
+			spvBalanceCmd.Usage()
+			os.Exit(1)
+		}
+		cli.spvBalance(*spvBalanceAddress, *spvBalanceNode)
+	}
+
+	if spvScanCmd.Parsed() {
+		if *spvScanAddress == "" || *spvScanNode == "" {
+			spvScanCmd.Usage()
+			os.Exit(1)
+		}
+		cli.spvScan(*spvScanAddress, *spvScanNode)
+	}
+
+	if getBlockFilterCmd.Parsed() {
+		if *getBlockFilterHash == "" {
+			getBlockFilterCmd.Usage()
+			os.Exit(1)
+		}
+		cli.getBlockFilter(*getBlockFilterHash)
+	}
+
+	if reindexFiltersCmd.Parsed() {
+		cli.reindexFilters()
+	}
+
+	if openChannelCmd.Parsed() {
+		if *openChannelFrom == "" || *openChannelTo == "" || *openChannelAmount <= 0 {
+			openChannelCmd.Usage()
+			os.Exit(1)
+		}
+		cli.openChannel(*openChannelFrom, *openChannelTo, *openChannelAmount, int64(*openChannelStake), *openChannelMine)
+	}
+
+	if channelPayCmd.Parsed() {
+		if *channelPayID == "" || *channelPayFrom == "" || *channelPayAmount <= 0 {
+			channelPayCmd.Usage()
+			os.Exit(1)
+		}
+		cli.channelPay(*channelPayID, *channelPayFrom, *channelPayAmount)
+	}
+
+	if closeChannelCmd.Parsed() {
+		if *closeChannelID == "" {
+			closeChannelCmd.Usage()
+			os.Exit(1)
+		}
+		cli.closeChannel(*closeChannelID, *closeChannelForce, int64(*closeChannelStake), *closeChannelMine)
+	}
+
+	if sweepChannelCmd.Parsed() {
+		if *sweepChannelID == "" || *sweepChannelAddress == "" {
+			sweepChannelCmd.Usage()
+			os.Exit(1)
+		}
+		cli.sweepChannel(*sweepChannelID, *sweepChannelAddress, int64(*sweepChannelStake), *sweepChannelMine)
+	}
+
+	if listChannelsCmd.Parsed() {
+		cli.listChannels()
+	}
+
+	if deployContractCmd.Parsed() {
+		if *deployContractFrom == "" || *deployContractCode == "" || *deployContractGasLimit <= 0 {
+			deployContractCmd.Usage()
+			os.Exit(1)
+		}
+		cli.deployContract(*deployContractFrom, *deployContractCode, *deployContractGasLimit, *deployContractGasPrice, int64(*deployContractStake), *deployContractMine, *deployContractFeeRate)
+	}
+
+	if callContractCmd.Parsed() {
+		if *callContractFrom == "" || *callContractID == "" || *callContractGasLimit <= 0 {
+			callContractCmd.Usage()
+			os.Exit(1)
+		}
+		cli.callContract(*callContractFrom, *callContractID, *callContractArgs, *callContractGasLimit, *callContractGasPrice, int64(*callContractStake), *callContractMine, *callContractFeeRate)
+	}
+
+	if getContractCmd.Parsed() {
+		if *getContractID == "" {
+			getContractCmd.Usage()
+			os.Exit(1)
+		}
+		cli.getContract(*getContractID)
+	}
+
+	if disconnectBlockCmd.Parsed() {
+		cli.disconnectBlock()
+	}
+
+	if proposeParamCmd.Parsed() {
+		if *proposeParamFrom == "" || *proposeParamName == "" {
+			proposeParamCmd.Usage()
+			os.Exit(1)
+		}
+		cli.proposeParam(*proposeParamFrom, *proposeParamName, *proposeParamValue, *proposeParamActivation, int64(*proposeParamStake), *proposeParamMine, *proposeParamFeeRate)
+	}
+
+	if voteParamCmd.Parsed() {
+		if *voteParamFrom == "" || *voteParamProposal == "" {
+			voteParamCmd.Usage()
+			os.Exit(1)
+		}
+		cli.voteParam(*voteParamFrom, *voteParamProposal, *voteParamApprove, int64(*voteParamStake), *voteParamMine, *voteParamFeeRate)
+	}
+
+	if listProposalsCmd.Parsed() {
+		cli.listProposals()
+	}
+
+	if getParamsCmd.Parsed() {
+		cli.getParams(*getParamsHeight)
+	}
+
+	if claimNameCmd.Parsed() {
+		if *claimNameFrom == "" || *claimNameName == "" {
+			claimNameCmd.Usage()
+			os.Exit(1)
+		}
+		cli.claimName(*claimNameFrom, *claimNameName, int64(*claimNameStake), *claimNameMine, *claimNameFeeRate)
+	}
+
+	if renewNameCmd.Parsed() {
+		if *renewNameFrom == "" || *renewNameName == "" {
+			renewNameCmd.Usage()
+			os.Exit(1)
+		}
+		cli.renewName(*renewNameFrom, *renewNameName, int64(*renewNameStake), *renewNameMine, *renewNameFeeRate)
+	}
+
+	if transferNameCmd.Parsed() {
+		if *transferNameFrom == "" || *transferNameName == "" || *transferNameTo == "" {
+			transferNameCmd.Usage()
+			os.Exit(1)
+		}
+		cli.transferName(*transferNameFrom, *transferNameName, *transferNameTo, int64(*transferNameStake), *transferNameMine, *transferNameFeeRate)
+	}
+
+	if resolveNameCmd.Parsed() {
+		if *resolveNameName == "" {
+			resolveNameCmd.Usage()
+			os.Exit(1)
+		}
+		cli.resolveName(*resolveNameName)
+	}
+
+	if encryptWalletCmd.Parsed() {
+		cli.encryptWallet(*encryptWalletPassphrase)
+	}
+
+	if walletPassphraseCmd.Parsed() {
+		if *walletPassphraseTimeout <= 0 {
+			walletPassphraseCmd.Usage()
+			os.Exit(1)
+		}
+		cli.walletPassphrase(*walletPassphrasePassphrase, *walletPassphraseTimeout)
+	}
+
+	if walletLockCmd.Parsed() {
+		cli.walletLock()
+	}
+
+	if restoreWalletCmd.Parsed() {
+		if *restoreWalletMnemonic == "" || *restoreWalletGapLimit <= 0 {
+			restoreWalletCmd.Usage()
+			os.Exit(1)
+		}
+		cli.restoreWallet(*restoreWalletMnemonic, *restoreWalletGapLimit)
+	}
+
+	if dumpPrivKeyCmd.Parsed() {
+		if *dumpPrivKeyAddress == "" {
+			dumpPrivKeyCmd.Usage()
+			os.Exit(1)
+		}
+		cli.dumpPrivKey(*dumpPrivKeyAddress)
+	}
+
+	if importPrivKeyCmd.Parsed() {
+		if *importPrivKeyKey == "" {
+			importPrivKeyCmd.Usage()
+			os.Exit(1)
+		}
+		cli.importPrivKey(*importPrivKeyKey, *importPrivKeyRescan)
+	}
+
+	if dumpWalletCmd.Parsed() {
+		if *dumpWalletFile == "" {
+			dumpWalletCmd.Usage()
+			os.Exit(1)
+		}
+		cli.dumpWallet(*dumpWalletFile)
+	}
+
+	if importWalletCmd.Parsed() {
+		if *importWalletFile == "" {
+			importWalletCmd.Usage()
+			os.Exit(1)
+		}
+		cli.importWallet(*importWalletFile, *importWalletRescan)
+	}
+
+	if bumpFeeCmd.Parsed() {
+		if *bumpFeeTxID == "" {
+			bumpFeeCmd.Usage()
+			os.Exit(1)
+		}
+		cli.bumpFee(*bumpFeeTxID, *bumpFeeRate)
+	}
+
+	if cpfpCmd.Parsed() {
+		if *cpfpTxID == "" || *cpfpFeeRate <= 0 {
+			cpfpCmd.Usage()
+			os.Exit(1)
+		}
+		cli.cpfp(*cpfpTxID, *cpfpFeeRate, *cpfpTo)
+	}
+
+	if createMultiSigCmd.Parsed() {
+		if *createMultiSigRequired <= 0 || *createMultiSigKeys == "" {
+			createMultiSigCmd.Usage()
+			os.Exit(1)
+		}
+		cli.createMultiSig(*createMultiSigRequired, *createMultiSigKeys)
+	}
+
+	if addMultiSigAddressCmd.Parsed() {
+		if *addMultiSigRequired <= 0 || *addMultiSigKeys == "" {
+			addMultiSigAddressCmd.Usage()
+			os.Exit(1)
+		}
+		cli.addMultiSigAddress(*addMultiSigRequired, *addMultiSigKeys)
+	}
+
+	if createSchnorrAddressCmd.Parsed() {
+		if *createSchnorrKeys == "" {
+			createSchnorrAddressCmd.Usage()
+			os.Exit(1)
+		}
+		cli.createSchnorrAddress(*createSchnorrKeys)
+	}
+
+	if signMultiSigCmd.Parsed() {
+		if *signMultiSigTx == "" {
+			signMultiSigCmd.Usage()
+			os.Exit(1)
+		}
+		cli.signMultiSig(*signMultiSigTx, int64(*signMultiSigStake))
+	}
+
+	if migrateWalletCmd.Parsed() {
+		cli.migrateWallet(*migrateWalletSweep, int64(*migrateWalletStake))
+	}
+
+	if getPubKeyCmd.Parsed() {
+		if *getPubKeyAddress == "" {
+			getPubKeyCmd.Usage()
+			os.Exit(1)
+		}
+		cli.getPubKey(*getPubKeyAddress)
+	}
+
+	if createRawTransactionCmd.Parsed() {
+		if *createRawFrom == "" || (len(createRawPayments) == 0 && *createRawFile == "") {
+			createRawTransactionCmd.Usage()
+			os.Exit(1)
+		}
+		cli.createRawTransaction(*createRawFrom, createRawPayments, *createRawFile, *createRawLockTime, *createRawCoinSelect, *createRawFeeRate)
+	}
+
+	if signRawTransactionCmd.Parsed() {
+		if *signRawTx == "" {
+			signRawTransactionCmd.Usage()
+			os.Exit(1)
+		}
+		cli.signRawTransaction(*signRawTx, *signRawSigHash)
+	}
+
+	if combineRawTransactionCmd.Parsed() {
+		if *combineRawTxs == "" {
+			combineRawTransactionCmd.Usage()
+			os.Exit(1)
+		}
+		cli.combineRawTransaction(*combineRawTxs)
+	}
+
+	if sendRawTransactionCmd.Parsed() {
+		if *sendRawTransactionTx == "" {
+			sendRawTransactionCmd.Usage()
+			os.Exit(1)
+		}
+		cli.sendRawTransaction(*sendRawTransactionTx)
+	}
+
+	if mineCmd.Parsed() {
+		if *mineAddress == "" {
+			mineCmd.Usage()
+			os.Exit(1)
+		}
+		cli.mine(*mineAddress, int64(*mineStake))
+	}
+
+	if initiateSwapCmd.Parsed() {
+		if *initiateSwapFrom == "" || *initiateSwapTo == "" || *initiateSwapAmount <= 0 || *initiateSwapLockTime <= 0 {
+			initiateSwapCmd.Usage()
+			os.Exit(1)
+		}
+		cli.initiateSwap(*initiateSwapFrom, *initiateSwapTo, *initiateSwapAmount, *initiateSwapLockTime, *initiateSwapSecretHash, int64(*initiateSwapStake))
+	}
+
+	if redeemSwapCmd.Parsed() {
+		if *redeemSwapContract == "" || *redeemSwapSecret == "" {
+			redeemSwapCmd.Usage()
+			os.Exit(1)
+		}
+		cli.redeemSwap(*redeemSwapContract, *redeemSwapSecret, int64(*redeemSwapStake))
+	}
+
+	if refundSwapCmd.Parsed() {
+		if *refundSwapContract == "" {
+			refundSwapCmd.Usage()
+			os.Exit(1)
+		}
+		cli.refundSwap(*refundSwapContract, int64(*refundSwapStake))
+	}
+
+	if auditSwapCmd.Parsed() {
+		if *auditSwapContract == "" {
+			auditSwapCmd.Usage()
+			os.Exit(1)
+		}
+		cli.auditSwap(*auditSwapContract)
+	}
+
+	if extractSecretCmd.Parsed() {
+		if *extractSecretContract == "" {
+			extractSecretCmd.Usage()
+			os.Exit(1)
+		}
+		cli.extractSecret(*extractSecretContract)
+	}
+
+	if notarizeCmd.Parsed() {
+		if *notarizeFile == "" || *notarizeFrom == "" {
+			notarizeCmd.Usage()
+			os.Exit(1)
+		}
+		cli.notarize(*notarizeFile, *notarizeFrom, int64(*notarizeStake))
+	}
+
+	if verifyNotarizationCmd.Parsed() {
+		if *verifyNotarizationFile == "" {
+			verifyNotarizationCmd.Usage()
+			os.Exit(1)
+		}
+		cli.verifyNotarization(*verifyNotarizationFile)
+	}
+
+	if crowdfundCmd.Parsed() {
+		if *crowdfundTo == "" || *crowdfundGoal <= 0 {
+			crowdfundCmd.Usage()
+			os.Exit(1)
+		}
+		cli.crowdfund(*crowdfundTo, *crowdfundGoal)
+	}
+
+	if contributeCmd.Parsed() {
+		if *contributeTx == "" || *contributeFrom == "" || *contributeAmount <= 0 {
+			contributeCmd.Usage()
+			os.Exit(1)
+		}
+		cli.contribute(*contributeTx, *contributeFrom, *contributeAmount, *contributeSigHash)
 	}
 }
\ No newline at end of file
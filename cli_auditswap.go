@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) auditSwap(contractHex string) {
+	script, err := DecodeContract(contractHex)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	bc := NewBlockchain()
+	UTXOSet := UTXOSet{bc}
+	defer bc.db.Close()
+
+	balance := 0
+	for _, out := range UTXOSet.FindUTXO(script.Hash()) {
+		balance += out.Value
+	}
+
+	height := bc.GetBestHeight() + 1
+	medianTime := bc.MedianTimePast(bc.tip)
+	refundTx := Transaction{LockTime: script.LockTime}
+
+	fmt.Printf("Contract address:  %s\n", script.GetAddress())
+	fmt.Printf("Contract value:    %d\n", balance)
+	fmt.Printf("Recipient address: %s\n", PubKeyHashToAddress(script.RecipientHash))
+	fmt.Printf("Refund address:    %s\n", PubKeyHashToAddress(script.RefundHash))
+	fmt.Printf("Secret hash:       %x\n", script.SecretHash)
+	if script.LockTime < lockTimeThreshold {
+		fmt.Printf("Lock time:         block %d\n", script.LockTime)
+	} else {
+		fmt.Printf("Lock time:         %d (unix time)\n", script.LockTime)
+	}
+	fmt.Printf("Refundable now:    %t\n", refundTx.IsFinal(height, medianTime))
+}
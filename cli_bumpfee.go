@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) bumpFee(txID string, feeRate int) {
+	if feeRate < 0 {
+		log.Panic("ERROR: Fee rate must not be negative")
+	}
+	id, err := hex.DecodeString(txID)
+	if err != nil {
+		log.Panic(err)
+	}
+	wallets, err := NewWallets()
+	if err != nil {
+		log.Panic(err)
+	}
+
+	bc := NewBlockchain()
+	UTXOSet := UTXOSet{bc}
+	mempool := Mempool{bc}
+	defer bc.db.Close()
+
+	tx, err := mempool.Get(id)
+	if err != nil {
+		log.Panic(err)
+	}
+	oldFee := UTXOSet.Fee(tx)
+
+	bump := NewFeeBump(tx, FeeRate(feeRate), wallets, &UTXOSet)
+	if err := mempool.AcceptTransaction(bump); err != nil {
+		log.Panic(err)
+	}
+
+	fmt.Printf("Transaction %x replaced by %x\n", tx.ID, bump.ID)
+	fmt.Printf("Fee raised from %d to %d\n", oldFee, UTXOSet.Fee(bump))
+}
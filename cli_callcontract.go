@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+func (cli *CLI) callContract(from, contract, argList string, gasLimit, gasPrice int, stake int64, mine bool, feeRate int) {
+	if !ValidateAddress(from) || IsScriptAddress(from) {
+		log.Panic("ERROR: Sender must be a wallet address")
+	}
+	id, err := hex.DecodeString(contract)
+	if err != nil {
+		log.Panic(err)
+	}
+	var args []int64
+	if argList != "" {
+		for _, field := range strings.Split(argList, ",") {
+			arg, err := strconv.ParseInt(strings.TrimSpace(field), 0, 64)
+			if err != nil {
+				log.Panic(err)
+			}
+			args = append(args, arg)
+		}
+	}
+	selection := parseCoinSelection("bnb", feeRate)
+
+	bc := NewBlockchain()
+	UTXOSet := UTXOSet{bc}
+	mempool := Mempool{bc}
+	defer bc.db.Close()
+
+	call := ContractCall{Contract: id, Args: args, GasLimit: gasLimit, GasPrice: gasPrice}
+	tx := NewContractTransaction(from, call, selection, &UTXOSet)
+	if !submitTransaction(mempool, tx) {
+		return
+	}
+	if !mine {
+		fmt.Printf("Transaction %x added to the mempool\n", tx.ID)
+		return
+	}
+
+	mempool.MineBlock(from, stake)
+	printContractReceipt(bc, tx.ID)
+}
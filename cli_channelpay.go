@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) channelPay(id, from string, amount int) {
+	wallets, err := NewWallets()
+	if err != nil {
+		log.Panic(err)
+	}
+	channel, err := wallets.GetChannel(id)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	if err := channel.Pay(from, amount, wallets); err != nil {
+		log.Panic(err)
+	}
+	wallets.SaveToFile()
+
+	fmt.Printf("Channel %s state %d:\n", channel.ID(), channel.State)
+	for _, party := range channel.Parties {
+		fmt.Printf("  %s: %d\n", party.Address, party.Balance)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) claimName(from, name string, stake int64, mine bool, feeRate int) {
+	cli.sendNameAction(from, NameAction{Op: NameClaim, Name: name}, stake, mine, feeRate)
+}
+
+// sendNameAction submits a name action paid by the wallet address from and
+// mines it unless mine is false
+func (cli *CLI) sendNameAction(from string, action NameAction, stake int64, mine bool, feeRate int) {
+	if !ValidateAddress(from) || IsScriptAddress(from) {
+		log.Panic("ERROR: Owner must be a wallet address")
+	}
+	selection := parseCoinSelection("bnb", feeRate)
+
+	bc := NewBlockchain()
+	UTXOSet := UTXOSet{bc}
+	mempool := Mempool{bc}
+	defer bc.db.Close()
+
+	tx := NewNameTransaction(from, action, selection, &UTXOSet)
+	if !submitTransaction(mempool, tx) {
+		return
+	}
+	if !mine {
+		fmt.Printf("Transaction %x added to the mempool\n", tx.ID)
+		return
+	}
+
+	mempool.MineBlock(from, stake)
+	record, err := bc.GetName(action.Name)
+	if err != nil {
+		log.Panic(err)
+	}
+	fmt.Printf("Success! %s is registered to %s until height %d\n", record.Name, PubKeyHashToAddress(record.Owner), record.Expires)
+}
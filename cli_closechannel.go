@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) closeChannel(id, force string, stake int64, mine bool) {
+	wallets, err := NewWallets()
+	if err != nil {
+		log.Panic(err)
+	}
+	channel, err := wallets.GetChannel(id)
+	if err != nil {
+		log.Panic(err)
+	}
+	if channel.IsClosed() {
+		log.Panicf("ERROR: Channel %s is already closed", id)
+	}
+
+	bc := NewBlockchain()
+	mempool := Mempool{bc}
+	defer bc.db.Close()
+
+	// A forced close broadcasts the latest commitment of one party, which
+	// takes its own balance with sweepchannel after the delay
+	var tx *Transaction
+	miner := channel.Parties[0].Address
+	if force != "" {
+		party := channel.Party(force)
+		if party < 0 {
+			log.Panicf("ERROR: %s is not a party of channel %s", force, id)
+		}
+		commitment := channel.Parties[party].Commitment
+		tx = &commitment
+		miner = force
+	} else {
+		tx = channel.NewCooperativeClose(wallets)
+	}
+
+	if !submitTransaction(mempool, tx) {
+		return
+	}
+	channel.ClosingTxID = tx.ID
+	wallets.SaveToFile()
+
+	fmt.Printf("Channel %s closed in transaction %x\n", id, tx.ID)
+	if force != "" {
+		fmt.Printf("Sweep the balance of %s with sweepchannel after %d blocks\n", force, channelDelay)
+	}
+	if !mine {
+		return
+	}
+
+	mempool.MineBlock(miner, stake)
+	fmt.Println("Success!")
+}
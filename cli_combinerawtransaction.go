@@ -0,0 +1,32 @@
+package main
+
+import (
+	"encoding/hex"
+	"log"
+	"strings"
+)
+
+func (cli *CLI) combineRawTransaction(txHexes string) {
+	var combined *PartialTransaction
+
+	for _, txHex := range strings.Split(txHexes, ",") {
+		data, err := hex.DecodeString(strings.TrimSpace(txHex))
+		if err != nil {
+			log.Panic(err)
+		}
+		pt, err := DeserializePartialTransaction(data)
+		if err != nil {
+			log.Panic(err)
+		}
+
+		if combined == nil {
+			combined = pt
+			continue
+		}
+		if err := combined.Combine(pt); err != nil {
+			log.Panic(err)
+		}
+	}
+
+	printPartialTransaction(combined)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) contribute(txHex, from string, amount int, hashTypeName string) {
+	if !ValidateAddress(from) || IsScriptAddress(from) {
+		log.Panic("ERROR: Sender address is not valid")
+	}
+	hashType, err := ParseSigHashType(hashTypeName)
+	if err != nil {
+		log.Panic(err)
+	}
+	data, err := hex.DecodeString(txHex)
+	if err != nil {
+		log.Panic(err)
+	}
+	tx := DeserializeTransaction(data)
+
+	bc := NewBlockchain()
+	UTXOSet := UTXOSet{bc}
+	defer bc.db.Close()
+
+	split := UTXOSet.AddContribution(&tx, from, amount, hashType)
+	mempool := Mempool{bc}
+	if split != nil {
+		if !submitTransaction(mempool, split) {
+			return
+		}
+		fmt.Printf("Split %d off the coins of %s in pending transaction %x, it is mined along with the crowdfunding\n", amount, from, split.ID)
+	}
+
+	goal, err := tx.OutputValue()
+	if err != nil {
+		log.Panic(err)
+	}
+	raised, err := bc.InputValue(&tx, mempool.pool())
+	if err != nil {
+		log.Panic(err)
+	}
+
+	fmt.Printf("Contributed %d, raised %d of %d\n", amount, raised, goal)
+	if raised >= goal {
+		fmt.Println("Goal reached, submit the transaction with sendrawtransaction:")
+	} else {
+		fmt.Println("Pass the transaction to the next contributor:")
+	}
+	fmt.Printf("%x\n", tx.Serialize())
+}
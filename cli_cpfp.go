@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) cpfp(txID string, feeRate int, to string) {
+	if feeRate <= 0 {
+		log.Panic("ERROR: Fee rate must be positive")
+	}
+	if to != "" && (!ValidateAddress(to) || IsScriptAddress(to)) {
+		log.Panic("ERROR: Recipient address is not valid")
+	}
+	id, err := hex.DecodeString(txID)
+	if err != nil {
+		log.Panic(err)
+	}
+	wallets, err := NewWallets()
+	if err != nil {
+		log.Panic(err)
+	}
+
+	bc := NewBlockchain()
+	mempool := Mempool{bc}
+	defer bc.db.Close()
+
+	parent, err := mempool.Get(id)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	// The child has to pay for every pending ancestor of the parent as well
+	entries := mempool.entries()
+	packageFee, packageSize := ancestors(txID, entries).feeAndSize(entries)
+
+	child := NewChildPaysForParent(parent, packageFee, packageSize, FeeRate(feeRate), wallets, to)
+	if err := mempool.AcceptTransaction(child); err != nil {
+		log.Panic(err)
+	}
+	childFee := mempool.Fee(child)
+
+	fmt.Printf("Transaction %x spends pending transaction %x\n", child.ID, parent.ID)
+	fmt.Printf("Package fee raised from %d to %d over %d bytes\n", packageFee, packageFee+childFee, packageSize+child.Size())
+}
@@ -9,7 +9,16 @@ func (cli *CLI) createBlockchain(address string) {
 	if !ValidateAddress(address) {
 		log.Panic("ERROR: Address is not valid")
 	}
-	bc := CreateBlockchain(address)
+	wallets, err := NewWallets()
+	if err != nil {
+		log.Panic(err)
+	}
+	proposer, err := wallets.ProposerKey(address)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	bc := CreateBlockchain(address, proposer.PrivateKey)
 	bc.db.Close()
 	fmt.Println("Done!")
 }
\ No newline at end of file
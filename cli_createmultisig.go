@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+)
+
+func (cli *CLI) createMultiSig(required int, keys string) {
+	wallets, _ := NewWallets()
+	script := newMultiSigFromKeys(required, keys, wallets)
+
+	fmt.Printf("Address: %s\n", script.GetAddress())
+	fmt.Printf("Redeem script: %x\n", script.Serialize())
+}
+
+func (cli *CLI) addMultiSigAddress(required int, keys string) {
+	wallets, _ := NewWallets()
+	script := newMultiSigFromKeys(required, keys, wallets)
+	address := wallets.AddScript(script)
+	wallets.SaveToFile()
+
+	fmt.Printf("Your new multisig address: %s\n", address)
+}
+
+// newMultiSigFromKeys builds a multisig script from a comma separated list
+// of wallet addresses or hex encoded public keys
+func newMultiSigFromKeys(required int, keys string, wallets *Wallets) *RedeemScript {
+	var pubKeys [][]byte
+
+	for _, key := range strings.Split(keys, ",") {
+		key = strings.TrimSpace(key)
+
+		if wallet, ok := wallets.Wallets[key]; ok {
+			pubKeys = append(pubKeys, wallet.PublicKey)
+			continue
+		}
+
+		pubKey, err := hex.DecodeString(key)
+		if err != nil {
+			log.Panicf("ERROR: %s is neither a wallet address nor a public key", key)
+		}
+		pubKeys = append(pubKeys, pubKey)
+	}
+
+	script, err := NewMultiSigScript(required, pubKeys)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return script
+}
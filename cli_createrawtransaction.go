@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) createRawTransaction(from string, payments []Payment, file string, lockTime int64, coinSelect string, feeRate int) {
+	if !ValidateAddress(from) {
+		log.Panic("ERROR: Sender address is not valid")
+	}
+	selection := parseCoinSelection(coinSelect, feeRate)
+	if file != "" {
+		filePayments, err := ReadPayments(file)
+		if err != nil {
+			log.Panic(err)
+		}
+		payments = append(payments, filePayments...)
+	}
+
+	bc := NewBlockchain()
+	UTXOSet := UTXOSet{bc}
+	defer bc.db.Close()
+
+	pt := NewRawTransaction(from, payments, lockTime, 0, selection, &UTXOSet)
+
+	fmt.Printf("Unsigned transaction %x, sign it with signrawtransaction:\n", pt.Tx.ID)
+	fmt.Printf("%x\n", pt.Serialize())
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+)
+
+func (cli *CLI) createSchnorrAddress(keys string) {
+	wallets, _ := NewWallets()
+
+	var pubKeys [][]byte
+	for _, key := range strings.Split(keys, ",") {
+		key = strings.TrimSpace(key)
+
+		// Wallet keys always take part in their compressed form
+		if wallet, ok := wallets.Wallets[key]; ok {
+			pubKeys = append(pubKeys, encodePubKey(&wallet.PrivateKey.PublicKey))
+			continue
+		}
+
+		pubKey, err := hex.DecodeString(key)
+		if err != nil {
+			log.Panicf("ERROR: %s is neither a wallet address nor a public key", key)
+		}
+		pubKeys = append(pubKeys, pubKey)
+	}
+
+	aggKey, err := AggregateKeys(pubKeys)
+	if err != nil {
+		log.Panic(err)
+	}
+	script, err := NewSchnorrScript(aggKey)
+	if err != nil {
+		log.Panic(err)
+	}
+	participants, err := sortMuSigKeys(pubKeys)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	address := wallets.AddAggregateScript(script, participants)
+	wallets.SaveToFile()
+
+	fmt.Printf("Your new Schnorr address: %s\n", address)
+	fmt.Printf("Aggregated key: %x\n", aggKey)
+}
@@ -1,11 +1,40 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"log"
+)
 
-func (cli *CLI) createWallet() {
+func (cli *CLI) createWallet(hd, change bool) {
 	wallets, _ := NewWallets()
-	address := wallets.CreateWallet()
+	if !hd {
+		address := wallets.CreateWallet()
+		wallets.SaveToFile()
+
+		fmt.Printf("Your new address: %s\n", address)
+		return
+	}
+
+	mnemonic := ""
+	if !wallets.IsHD() {
+		var err error
+		if mnemonic, err = wallets.CreateHDSeed(); err != nil {
+			log.Panic(err)
+		}
+	}
+	chain := uint32(HDReceiveChain)
+	if change {
+		chain = HDChangeChain
+	}
+	address, err := wallets.NewHDAddress(chain)
+	if err != nil {
+		log.Panic(err)
+	}
 	wallets.SaveToFile()
 
-	fmt.Printf("Your new address: %s\n", address)
-}
\ No newline at end of file
+	if mnemonic != "" {
+		fmt.Println("Write down the mnemonic of your HD wallet, it restores all its keys:")
+		fmt.Println(mnemonic)
+	}
+	fmt.Printf("Your new address: %s (%s)\n", address, wallets.Wallets[address].Path)
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) crowdfund(to string, goal int) {
+	if !ValidateAddress(to) {
+		log.Panic("ERROR: Address is not valid")
+	}
+
+	tx := NewCrowdfundTransaction(to, goal)
+	fmt.Printf("Crowdfunding %d for %s, pass this transaction to contributors:\n", goal, to)
+	fmt.Printf("%x\n", tx.Serialize())
+}
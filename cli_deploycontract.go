@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+)
+
+func (cli *CLI) deployContract(from, codeFile string, gasLimit, gasPrice int, stake int64, mine bool, feeRate int) {
+	if !ValidateAddress(from) || IsScriptAddress(from) {
+		log.Panic("ERROR: Sender must be a wallet address")
+	}
+	source, err := ioutil.ReadFile(codeFile)
+	if err != nil {
+		log.Panic(err)
+	}
+	code, err := AssembleContract(string(source))
+	if err != nil {
+		log.Panic(err)
+	}
+	selection := parseCoinSelection("bnb", feeRate)
+
+	bc := NewBlockchain()
+	UTXOSet := UTXOSet{bc}
+	mempool := Mempool{bc}
+	defer bc.db.Close()
+
+	call := ContractCall{Code: code, GasLimit: gasLimit, GasPrice: gasPrice}
+	tx := NewContractTransaction(from, call, selection, &UTXOSet)
+	if !submitTransaction(mempool, tx) {
+		return
+	}
+	fmt.Printf("Contract: %x\n", NewContractID(tx.Vin[0].Txid, tx.Vin[0].Vout))
+	if !mine {
+		fmt.Printf("Transaction %x added to the mempool\n", tx.ID)
+		return
+	}
+
+	mempool.MineBlock(from, stake)
+	printContractReceipt(bc, tx.ID)
+}
+
+func printContractReceipt(bc *Blockchain, txID []byte) {
+	receipt, err := bc.GetContractReceipt(txID)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	if receipt.Success {
+		fmt.Printf("Success! Result %d, gas used %d\n", receipt.Result, receipt.GasUsed)
+	} else {
+		fmt.Printf("Failed: %s, gas used %d\n", receipt.Error, receipt.GasUsed)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) disconnectBlock() {
+	bc := NewBlockchain()
+	mempool := Mempool{bc}
+	defer bc.db.Close()
+
+	block, err := bc.DisconnectTip()
+	if err != nil {
+		log.Panic(err)
+	}
+	fmt.Printf("Disconnected block %x at height %d\n", block.Hash, block.Height)
+
+	// The block's transactions are pending again unless they became invalid
+	for _, tx := range block.Transactions {
+		if tx.IsCoinbase() {
+			continue
+		}
+		if err := mempool.AcceptTransaction(tx); err != nil {
+			fmt.Printf("Dropped transaction %x: %s\n", tx.ID, err)
+		}
+	}
+}
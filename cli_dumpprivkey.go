@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) dumpPrivKey(address string) {
+	wallets, err := NewWallets()
+	if err != nil {
+		log.Panic(err)
+	}
+	wallet, ok := wallets.Wallets[address]
+	if !ok {
+		log.Panic("ERROR: Address has no private key in the wallet file")
+	}
+
+	key, err := EncodePrivateKey(wallet)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	fmt.Println(key)
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) dumpWallet(file string) {
+	wallets, err := NewWallets()
+	if err != nil {
+		log.Panic(err)
+	}
+
+	var dump bytes.Buffer
+	if err := wallets.DumpKeys(&dump); err != nil {
+		log.Panic(err)
+	}
+	if err := writePrivateFile(file, dump.Bytes()); err != nil {
+		log.Panic(err)
+	}
+
+	fmt.Printf("Dumped %d keys to %s\n", len(wallets.Wallets), file)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+func (cli *CLI) encryptWallet(passphrase string) {
+	passphrase = readPassphrase(passphrase)
+
+	wallets, err := NewWallets()
+	if err != nil {
+		log.Panic(err)
+	}
+	if err := wallets.Encrypt(passphrase); err != nil {
+		log.Panic(err)
+	}
+	wallets.SaveToFile()
+
+	fmt.Println("Wallet encrypted, it stays locked until walletpassphrase unlocks it")
+	fmt.Println("The passphrase cannot be recovered, without it the keys are lost")
+}
+
+// readPassphrase returns the -passphrase flag, or a line read from stdin
+// when it is empty so the passphrase needs not appear in the shell history
+func readPassphrase(passphrase string) string {
+	if passphrase != "" {
+		return passphrase
+	}
+
+	fmt.Print("Passphrase: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		log.Panic(err)
+	}
+
+	return strings.TrimRight(line, "\r\n")
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) extractSecret(contractHex string) {
+	script, err := DecodeContract(contractHex)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	bc := NewBlockchain()
+	defer bc.db.Close()
+
+	secret, err := bc.FindSwapSecret(script)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	fmt.Printf("Secret: %x\n", secret)
+}
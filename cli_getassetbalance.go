@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sort"
+)
+
+func (cli *CLI) getAssetBalance(address string) {
+	if !ValidateAddress(address) {
+		log.Panic("ERROR: Address is not valid")
+	}
+	bc := NewBlockchain()
+	UTXOSet := UTXOSet{bc}
+	defer bc.db.Close()
+
+	balances := UTXOSet.GetAssetBalances(AddressToPubKeyHash(address))
+	var ids []string
+	for id := range balances {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	fmt.Printf("Assets of '%s':\n", address)
+	for _, id := range ids {
+		assetID, err := hex.DecodeString(id)
+		if err != nil {
+			log.Panic(err)
+		}
+		issuance, err := bc.FindAsset(assetID)
+		if err != nil {
+			log.Panic(err)
+		}
+
+		fmt.Printf("  %s %d %s\n", id, balances[id], issuance.Name)
+	}
+}
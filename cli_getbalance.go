@@ -13,14 +13,10 @@ func (cli *CLI) getBalance(address string) {
 	UTXOSet := UTXOSet{bc}
 	defer bc.db.Close()
 
-	balance := 0
 	pubKeyHash := Base58Decode([]byte(address))
 	pubKeyHash = pubKeyHash[1 : len(pubKeyHash)-4]
-	UTXOs := UTXOSet.FindUTXO(pubKeyHash)
-
-	for _, out := range UTXOs {
-		balance += out.Value
-	}
+	balance, immature := UTXOSet.GetBalance(pubKeyHash)
 
 	fmt.Printf("Balance of '%s': %d\n", address, balance)
+	fmt.Printf("Immature coinbase balance: %d\n", immature)
 }
\ No newline at end of file
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) getBlockFilter(blockHash string) {
+	hash, err := hex.DecodeString(blockHash)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	bc := NewBlockchain()
+	defer bc.db.Close()
+
+	filter, err := bc.GetFilter(hash)
+	if err != nil {
+		log.Panic(err)
+	}
+	header, err := bc.GetFilterHeader(hash)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	fmt.Printf("Items:  %d\n", filter.N)
+	fmt.Printf("Filter: %x\n", filter.Serialize())
+	fmt.Printf("Header: %x\n", header)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sort"
+)
+
+func (cli *CLI) getContract(contract string) {
+	id, err := hex.DecodeString(contract)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	bc := NewBlockchain()
+	defer bc.db.Close()
+
+	code, storage, err := bc.GetContract(id)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	var keys []int64
+	for key := range storage {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	fmt.Printf("Contract %x, %d bytes of code\n", id, len(code))
+	for _, key := range keys {
+		fmt.Printf("  %d: %d\n", key, storage[key])
+	}
+}
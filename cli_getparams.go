@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+func (cli *CLI) getParams(height int) {
+	bc := NewBlockchain()
+	defer bc.db.Close()
+
+	if height < 0 {
+		height = bc.GetBestHeight() + 1
+	}
+	params := bc.ChainParamsAt(height)
+
+	var names []string
+	for name := range governableParams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Printf("Chain parameters at height %d:\n", height)
+	for _, name := range names {
+		fmt.Printf("  %s: %d\n", name, *governableParams[name].field(&params))
+	}
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) getPubKey(address string) {
+	wallets, err := NewWallets()
+	if err != nil {
+		log.Panic(err)
+	}
+	wallet, ok := wallets.Wallets[address]
+	if !ok {
+		log.Panic("ERROR: Address is not in the wallet file")
+	}
+
+	// Share the compressed key, which Schnorr aggregation requires and
+	// multisig accepts for legacy wallets as well
+	fmt.Printf("%x\n", encodePubKey(&wallet.PrivateKey.PublicKey))
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) importPrivKey(key string, rescan bool) {
+	wallet, err := DecodePrivateKey(key)
+	if err != nil {
+		log.Panic(err)
+	}
+	wallets, _ := NewWallets()
+	if wallets.IsLocked() {
+		log.Panic(ErrWalletLocked)
+	}
+
+	address, added := wallets.ImportKey(wallet)
+	if !added {
+		fmt.Printf("Address %s is already in the wallet file\n", address)
+		return
+	}
+	wallets.SaveToFile()
+
+	fmt.Printf("Imported address: %s\n", address)
+	if rescan {
+		rescanAddresses([]string{address})
+	}
+}
+
+// rescanAddresses prints the outputs ever paid to the addresses and their
+// balances
+func rescanAddresses(addresses []string) {
+	bc := NewBlockchain()
+	UTXOSet := UTXOSet{bc}
+	defer bc.db.Close()
+
+	received := bc.Rescan(addresses)
+	for _, address := range addresses {
+		balance, _ := UTXOSet.GetBalance(AddressToPubKeyHash(address))
+		fmt.Printf("%s: received %d outputs of %d in total, balance %d\n", address, received[address].Count, received[address].Value, balance)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+func (cli *CLI) importWallet(file string, rescan bool) {
+	f, err := os.Open(file)
+	if err != nil {
+		log.Panic(err)
+	}
+	imported, err := ReadKeyDump(f)
+	f.Close()
+	if err != nil {
+		log.Panic(err)
+	}
+
+	wallets, _ := NewWallets()
+	if wallets.IsLocked() {
+		log.Panic(ErrWalletLocked)
+	}
+
+	var addresses []string
+	for _, wallet := range imported {
+		if address, added := wallets.ImportKey(wallet); added {
+			addresses = append(addresses, address)
+		}
+	}
+	wallets.SaveToFile()
+
+	fmt.Printf("Imported %d of %d keys\n", len(addresses), len(imported))
+	if rescan && len(addresses) > 0 {
+		rescanAddresses(addresses)
+	}
+}
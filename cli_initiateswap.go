@@ -0,0 +1,60 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) initiateSwap(from, to string, amount int, lockTime int64, secretHashHex string, stake int64) {
+	if !ValidateAddress(from) || IsScriptAddress(from) {
+		log.Panic("ERROR: Sender address is not valid")
+	}
+	if !ValidateAddress(to) || IsScriptAddress(to) {
+		log.Panic("ERROR: Recipient address is not valid")
+	}
+
+	// The initiator makes up the secret, the participant reuses its hash
+	var secret, secretHash []byte
+	if secretHashHex == "" {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			log.Panic(err)
+		}
+		hash := sha256.Sum256(secret)
+		secretHash = hash[:]
+	} else {
+		var err error
+		secretHash, err = hex.DecodeString(secretHashHex)
+		if err != nil {
+			log.Panic(err)
+		}
+	}
+
+	script, err := NewHTLCScript(secretHash, AddressToPubKeyHash(to), AddressToPubKeyHash(from), lockTime)
+	if err != nil {
+		log.Panic(err)
+	}
+	contractAddress := fmt.Sprintf("%s", script.GetAddress())
+
+	bc := NewBlockchain()
+	UTXOSet := UTXOSet{bc}
+	mempool := Mempool{bc}
+	defer bc.db.Close()
+
+	tx := NewUTXOTransaction(from, contractAddress, amount, 0, 0, &UTXOSet)
+	if !submitTransaction(mempool, tx) {
+		return
+	}
+	mempool.MineBlock(from, stake)
+
+	if secret != nil {
+		fmt.Printf("Secret:           %x\n", secret)
+	}
+	fmt.Printf("Secret hash:      %x\n", secretHash)
+	fmt.Printf("Contract:         %x\n", script.Serialize())
+	fmt.Printf("Contract address: %s\n", contractAddress)
+	fmt.Printf("Contract tx:      %x\n", tx.ID)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) issueAsset(from, to, name string, supply int, metadata string, stake int64, mine bool, feeRate int) {
+	if !ValidateAddress(from) || IsScriptAddress(from) {
+		log.Panic("ERROR: Issuer must be a wallet address")
+	}
+	if to == "" {
+		to = from
+	}
+	if !ValidateAddress(to) {
+		log.Panic("ERROR: Recipient address is not valid")
+	}
+	selection := parseCoinSelection("bnb", feeRate)
+
+	bc := NewBlockchain()
+	UTXOSet := UTXOSet{bc}
+	mempool := Mempool{bc}
+	defer bc.db.Close()
+
+	issuance := AssetIssuance{name, supply, []byte(metadata)}
+	tx := NewIssuanceTransaction(from, to, issuance, selection, &UTXOSet)
+	if !submitTransaction(mempool, tx) {
+		return
+	}
+
+	fmt.Printf("Asset %x issues %d %s to %s\n", tx.Vout[0].Asset.ID, supply, name, to)
+	if !mine {
+		fmt.Printf("Transaction %x added to the mempool\n", tx.ID)
+		return
+	}
+
+	mempool.MineBlock(from, stake)
+	fmt.Println("Success!")
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) listChannels() {
+	wallets, err := NewWallets()
+	if err != nil {
+		log.Panic(err)
+	}
+
+	for id, channel := range wallets.Channels {
+		status := "open"
+		if channel.IsClosed() {
+			status = fmt.Sprintf("closed in %x", channel.ClosingTxID)
+		}
+
+		fmt.Printf("Channel %s, capacity %d, state %d, %s\n", id, channel.Capacity, channel.State, status)
+		for _, party := range channel.Parties {
+			fmt.Printf("  %s: %d\n", party.Address, party.Balance)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) listNFTs(address, nftID string) {
+	bc := NewBlockchain()
+	UTXOSet := UTXOSet{bc}
+	defer bc.db.Close()
+
+	if nftID != "" {
+		id, err := hex.DecodeString(nftID)
+		if err != nil {
+			log.Panic(err)
+		}
+		loc, err := UTXOSet.FindNFTOwner(id)
+		if err != nil {
+			log.Panic(err)
+		}
+
+		fmt.Printf("NFT %s is held by %s in output %x:%d\n", nftID, PubKeyHashToAddress(loc.PubKeyHash), loc.TxID, loc.Index)
+		return
+	}
+
+	if !ValidateAddress(address) {
+		log.Panic("ERROR: Address is not valid")
+	}
+	fmt.Printf("NFTs of '%s':\n", address)
+	for _, coin := range UTXOSet.FindNFTs(AddressToPubKeyHash(address)) {
+		fmt.Printf("  %x\n", coin.NFT.ID)
+		fmt.Printf("    Issuer:   %s\n", PubKeyHashToAddress(coin.NFT.Issuer))
+		fmt.Printf("    URI:      %s\n", coin.NFT.URI)
+		fmt.Printf("    Metadata: %x\n", coin.NFT.MetadataHash)
+	}
+}
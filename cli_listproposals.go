@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) listProposals() {
+	bc := NewBlockchain()
+	defer bc.db.Close()
+
+	proposals, err := bc.Proposals()
+	if err != nil {
+		log.Panic(err)
+	}
+
+	for _, p := range proposals {
+		fmt.Printf("Proposal %x: %s = %d at height %d\n", p.ID, p.Param, p.Value, p.Activation)
+		fmt.Printf("  Proposer: %s\n", PubKeyHashToAddress(p.Proposer))
+		fmt.Printf("  Voting:   heights %d to %d\n", p.Start, p.End)
+		if p.Status == ProposalVoting {
+			fmt.Printf("  Status:   %s\n", p.StatusString())
+			continue
+		}
+		fmt.Printf("  Status:   %s with %d for and %d against of %d coins\n", p.StatusString(), p.Yes, p.No, p.Supply)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+func (cli *CLI) migrateWallet(sweep bool, stake int64) {
+	wallets, _ := NewWallets()
+	migrated := wallets.MigrateKeys()
+	if len(migrated) == 0 {
+		fmt.Println("All legacy wallets already have compressed key addresses")
+	} else {
+		wallets.SaveToFile()
+	}
+
+	legacy := wallets.LegacyAddresses()
+	var legacyAddresses []string
+	for address := range legacy {
+		legacyAddresses = append(legacyAddresses, address)
+	}
+	sort.Strings(legacyAddresses)
+
+	for _, address := range legacyAddresses {
+		if _, ok := migrated[address]; !ok {
+			continue
+		}
+		fmt.Printf("%s -> %s\n", address, migrated[address])
+		if len(wallets.Wallets[address].PublicKey) != legacyPubKeyLen {
+			fmt.Println("  legacy public key is not fixed-width, coins on this address cannot be spent")
+		}
+	}
+	if !sweep {
+		return
+	}
+
+	bc := NewBlockchain()
+	UTXOSet := UTXOSet{bc}
+	mempool := Mempool{bc}
+	defer bc.db.Close()
+
+	swept := ""
+	for _, address := range legacyAddresses {
+		if len(wallets.Wallets[address].PublicKey) != legacyPubKeyLen {
+			continue
+		}
+
+		balance, _ := UTXOSet.GetBalance(AddressToPubKeyHash(address))
+		if balance == 0 {
+			continue
+		}
+
+		tx := NewUTXOTransaction(address, legacy[address], balance, 0, 0, &UTXOSet)
+		if !submitTransaction(mempool, tx) {
+			return
+		}
+		fmt.Printf("Sweeping %d from %s\n", balance, address)
+		swept = legacy[address]
+	}
+
+	if swept == "" {
+		fmt.Println("No legacy coins to sweep")
+		return
+	}
+
+	mempool.MineBlock(swept, stake)
+	fmt.Println("Success!")
+}
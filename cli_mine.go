@@ -0,0 +1,18 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) mine(address string, stake int64) {
+	if !ValidateAddress(address) {
+		log.Panic("ERROR: Address is not valid")
+	}
+
+	bc := NewBlockchain()
+	defer bc.db.Close()
+
+	newBlock := Mempool{bc}.MineBlock(address, stake)
+	fmt.Printf("Mined block %x with %d transaction(s)\n", newBlock.Hash, len(newBlock.Transactions))
+}
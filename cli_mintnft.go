@@ -0,0 +1,57 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+)
+
+func (cli *CLI) mintNFT(from, to, uri, metadataFile, metadataHash string, stake int64, mine bool, feeRate int) {
+	if !ValidateAddress(from) || IsScriptAddress(from) {
+		log.Panic("ERROR: Issuer must be a wallet address")
+	}
+	if to == "" {
+		to = from
+	}
+	if !ValidateAddress(to) || IsScriptAddress(to) {
+		log.Panic("ERROR: Recipient must be a wallet address")
+	}
+
+	var hash []byte
+	if metadataFile != "" {
+		data, err := os.ReadFile(metadataFile)
+		if err != nil {
+			log.Panic(err)
+		}
+		sum := sha256.Sum256(data)
+		hash = sum[:]
+	} else {
+		var err error
+		hash, err = hex.DecodeString(metadataHash)
+		if err != nil || len(hash) != sha256.Size {
+			log.Panic("ERROR: Metadata hash must be a hex SHA-256 hash")
+		}
+	}
+	selection := parseCoinSelection("bnb", feeRate)
+
+	bc := NewBlockchain()
+	UTXOSet := UTXOSet{bc}
+	mempool := Mempool{bc}
+	defer bc.db.Close()
+
+	tx := NewMintTransaction(from, to, hash, uri, selection, &UTXOSet)
+	if !submitTransaction(mempool, tx) {
+		return
+	}
+
+	fmt.Printf("NFT %x minted to %s\n", tx.Vout[0].NFT.ID, to)
+	if !mine {
+		fmt.Printf("Transaction %x added to the mempool\n", tx.ID)
+		return
+	}
+
+	mempool.MineBlock(from, stake)
+	fmt.Println("Success!")
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+func (cli *CLI) notarize(file, from string, stake int64) {
+	if !ValidateAddress(from) || IsScriptAddress(from) {
+		log.Panic("ERROR: Sender address is not valid")
+	}
+	document, err := os.ReadFile(file)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	bc := NewBlockchain()
+	UTXOSet := UTXOSet{bc}
+	mempool := Mempool{bc}
+	defer bc.db.Close()
+
+	tx := NewDataTransaction(from, NotarizationPayload(document), &UTXOSet)
+	if !submitTransaction(mempool, tx) {
+		return
+	}
+	newBlock := mempool.MineBlock(from, stake)
+
+	fmt.Printf("Notarized %s in transaction %x, block %d\n", file, tx.ID, newBlock.Height)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) openChannel(from, to string, amount int, stake int64, mine bool) {
+	if !ValidateAddress(from) || IsScriptAddress(from) {
+		log.Panic("ERROR: Sender must be a wallet address")
+	}
+	if !ValidateAddress(to) || IsScriptAddress(to) {
+		log.Panic("ERROR: Counterparty must be a wallet address")
+	}
+
+	wallets, err := NewWallets()
+	if err != nil {
+		log.Panic(err)
+	}
+
+	bc := NewBlockchain()
+	UTXOSet := UTXOSet{bc}
+	mempool := Mempool{bc}
+	defer bc.db.Close()
+
+	channel, funding := NewChannel(from, to, amount, wallets, &UTXOSet)
+	if !submitTransaction(mempool, funding) {
+		return
+	}
+	wallets.Channels[channel.ID()] = channel
+	wallets.SaveToFile()
+
+	fmt.Printf("Channel %s opened with %d\n", channel.ID(), amount)
+	if !mine {
+		fmt.Printf("Transaction %x added to the mempool\n", funding.ID)
+		return
+	}
+
+	mempool.MineBlock(from, stake)
+	fmt.Println("Success!")
+}
@@ -16,7 +16,7 @@ func (cli *CLI) printChain() {
 
 		fmt.Printf("============ Block %x ============\n", block.Hash)
 		fmt.Printf("Prev. block: %x\n", block.PrevBlockHash)
-		pos := NewProofOfStake(block)
+		pos := NewProofOfStake(block, bc.ChainParamsAt(block.Height).StakeThreshold)
 		fmt.Printf("Stake: %d\n", block.Stake)
 		fmt.Printf("PoS: %s\n\n", strconv.FormatBool(pos.Validate()))
 		for _, tx := range block.Transactions {
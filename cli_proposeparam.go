@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) proposeParam(from, param string, value, activation int, stake int64, mine bool, feeRate int) {
+	if !ValidateAddress(from) || IsScriptAddress(from) {
+		log.Panic("ERROR: Proposer must be a wallet address")
+	}
+	selection := parseCoinSelection("bnb", feeRate)
+
+	bc := NewBlockchain()
+	UTXOSet := UTXOSet{bc}
+	mempool := Mempool{bc}
+	defer bc.db.Close()
+
+	if activation == 0 {
+		activation = bc.GetBestHeight() + 1 + governanceVotingPeriod + governanceActivationDelay
+	}
+
+	action := GovernanceAction{Param: param, Value: value, Activation: activation}
+	tx := NewGovernanceTransaction(from, action, selection, &UTXOSet)
+	if !submitTransaction(mempool, tx) {
+		return
+	}
+	fmt.Printf("Proposal: %x\n", tx.ID)
+	if !mine {
+		fmt.Printf("Transaction %x added to the mempool\n", tx.ID)
+		return
+	}
+
+	mempool.MineBlock(from, stake)
+	fmt.Println("Success!")
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) redeemSwap(contractHex, secretHex string, stake int64) {
+	script, err := DecodeContract(contractHex)
+	if err != nil {
+		log.Panic(err)
+	}
+	secret, err := hex.DecodeString(secretHex)
+	if err != nil {
+		log.Panic(err)
+	}
+	secretHash := sha256.Sum256(secret)
+	if !bytes.Equal(secretHash[:], script.SecretHash) {
+		log.Panic("ERROR: Secret does not match the contract's secret hash")
+	}
+
+	wallets, err := NewWallets()
+	if err != nil {
+		log.Panic(err)
+	}
+	wallet := wallets.FindByPubKeyHash(script.RecipientHash)
+	if wallet == nil {
+		log.Panic("ERROR: The contract recipient is not in the wallet file")
+	}
+
+	bc := NewBlockchain()
+	UTXOSet := UTXOSet{bc}
+	mempool := Mempool{bc}
+	defer bc.db.Close()
+
+	tx := NewHTLCSpend(script, secret, wallet, &UTXOSet)
+	if !submitTransaction(mempool, tx) {
+		return
+	}
+	mempool.MineBlock(fmt.Sprintf("%s", wallet.GetAddress()), stake)
+
+	fmt.Printf("Redeemed contract in transaction %x\n", tx.ID)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) refundSwap(contractHex string, stake int64) {
+	script, err := DecodeContract(contractHex)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	wallets, err := NewWallets()
+	if err != nil {
+		log.Panic(err)
+	}
+	wallet := wallets.FindByPubKeyHash(script.RefundHash)
+	if wallet == nil {
+		log.Panic("ERROR: The contract refunder is not in the wallet file")
+	}
+
+	bc := NewBlockchain()
+	UTXOSet := UTXOSet{bc}
+	mempool := Mempool{bc}
+	defer bc.db.Close()
+
+	tx := NewHTLCSpend(script, nil, wallet, &UTXOSet)
+	if !submitTransaction(mempool, tx) {
+		return
+	}
+	mempool.MineBlock(fmt.Sprintf("%s", wallet.GetAddress()), stake)
+
+	fmt.Printf("Refunded contract in transaction %x\n", tx.ID)
+}
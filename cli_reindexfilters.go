@@ -0,0 +1,11 @@
+package main
+
+import "fmt"
+
+func (cli *CLI) reindexFilters() {
+	bc := NewBlockchain()
+	defer bc.db.Close()
+
+	count := bc.ReindexFilters()
+	fmt.Printf("Done! Indexed the filters of %d blocks.\n", count)
+}
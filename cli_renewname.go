@@ -0,0 +1,5 @@
+package main
+
+func (cli *CLI) renewName(from, name string, stake int64, mine bool, feeRate int) {
+	cli.sendNameAction(from, NameAction{Op: NameRenew, Name: name}, stake, mine, feeRate)
+}
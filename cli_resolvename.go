@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) resolveName(name string) {
+	bc := NewBlockchain()
+	defer bc.db.Close()
+
+	record, err := bc.GetName(name)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	height := bc.GetBestHeight()
+	if record.Expires < height {
+		fmt.Printf("%s expired at height %d and can be claimed\n", record.Name, record.Expires)
+		return
+	}
+	fmt.Printf("%s: %s until height %d\n", record.Name, PubKeyHashToAddress(record.Owner), record.Expires)
+}
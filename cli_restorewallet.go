@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) restoreWallet(mnemonic string, gapLimit int) {
+	wallets, _ := NewWallets()
+	if err := wallets.RestoreHDSeed(mnemonic); err != nil {
+		log.Panic(err)
+	}
+
+	used := make(map[string]bool)
+	if dbExists() {
+		bc := NewBlockchain()
+		used = bc.PaidPubKeyHashes()
+		bc.db.Close()
+	} else {
+		fmt.Println("No existing blockchain found, only the first address is restored")
+	}
+
+	found, err := wallets.DiscoverHDAddresses(used, gapLimit)
+	if err != nil {
+		log.Panic(err)
+	}
+	wallets.SaveToFile()
+
+	fmt.Printf("Restored %d addresses\n", found)
+	for _, chain := range []uint32{HDReceiveChain, HDChangeChain} {
+		for index := uint32(0); index < wallets.HD.Next[chain]; index++ {
+			path := FormatHDPath(hdPath(chain, index))
+			for address, wallet := range wallets.Wallets {
+				if wallet.Path == path {
+					fmt.Printf("%s (%s)\n", address, path)
+				}
+			}
+		}
+	}
+}
@@ -1,27 +1,123 @@
 package main
 
 import (
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
 )
 
-func (cli *CLI) send(from, to string, amount int, stake int64) {
+func (cli *CLI) send(from, to string, amount int, asset string, stake int64, lockTime int64, sequence uint32, mine bool, coinSelect string, feeRate int) {
 	if !ValidateAddress(from) {
 		log.Panic("ERROR: Sender address is not valid")
 	}
-	if !ValidateAddress(to) {
-		log.Panic("ERROR: Recipient address is not valid")
-	}
+	selection := parseCoinSelection(coinSelect, feeRate)
 
 	bc := NewBlockchain()
 	UTXOSet := UTXOSet{bc}
+	mempool := Mempool{bc}
 	defer bc.db.Close()
 
-	tx := NewUTXOTransaction(from, to, amount, &UTXOSet)
-	cbTx := NewCoinbaseTX(from, "")
-	txs := []*Transaction{cbTx, tx}
+	// Names start with a letter, addresses with a digit
+	if ValidateName(to) == nil {
+		address, err := bc.ResolveName(to, bc.GetBestHeight())
+		if err != nil {
+			log.Panic(err)
+		}
+		fmt.Printf("Name %s resolves to %s\n", to, address)
+		to = address
+	}
+	if !ValidateAddress(to) {
+		log.Panic("ERROR: Recipient address is not valid")
+	}
+
+	var tx *Transaction
+	if asset != "" {
+		assetID, err := hex.DecodeString(asset)
+		if err != nil || len(assetID) != assetIDLen {
+			log.Panic("ERROR: Asset ID is not valid")
+		}
+		if IsScriptAddress(from) {
+			log.Panic("ERROR: Assets can only be sent from wallet addresses")
+		}
+		tx = NewAssetTransaction(from, to, assetID, amount, lockTime, sequence, selection, &UTXOSet)
+	} else if isSchnorrAddress(from) {
+		wallets, err := NewWallets()
+		if err != nil {
+			log.Panic(err)
+		}
+		pt := NewRawTransaction(from, []Payment{{to, amount}}, lockTime, sequence, selection, &UTXOSet)
+		pt.Sign(wallets, SigHashAll)
+		wallets.SaveToFile()
+		if !pt.IsComplete() {
+			printPartialTransaction(pt)
+			return
+		}
+		tx = &pt.Tx
+	} else if IsScriptAddress(from) {
+		tx = NewMultiSigTransaction(from, to, amount, lockTime, sequence, &UTXOSet)
+		if tx.MissingSignatures() > 0 {
+			printPartialMultiSig(tx)
+			return
+		}
+	} else {
+		tx = NewBatchTransaction(from, []Payment{{to, amount}}, lockTime, sequence, selection, &UTXOSet)
+	}
+
+	if !submitTransaction(mempool, tx) {
+		return
+	}
+	if !mine {
+		fmt.Printf("Transaction %x added to the mempool\n", tx.ID)
+		return
+	}
 
-	newBlock := bc.MineBlock(txs, stake)
-	UTXOSet.Update(newBlock)
+	mempool.MineBlock(from, stake)
 	fmt.Println("Success!")
+}
+
+// isSchnorrAddress checks whether an address is a Schnorr script address of
+// the local wallet
+func isSchnorrAddress(address string) bool {
+	if !IsScriptAddress(address) {
+		return false
+	}
+	wallets, err := NewWallets()
+	if err != nil {
+		return false
+	}
+	script, err := wallets.GetScript(address)
+
+	return err == nil && script.Type == ScriptSchnorr
+}
+
+// parseCoinSelection returns the coin selection for the -coinselect and
+// -feerate flags
+func parseCoinSelection(name string, feeRate int) CoinSelection {
+	selector, err := ParseCoinSelector(name)
+	if err != nil {
+		log.Panic(err)
+	}
+	if feeRate < 0 {
+		log.Panic("ERROR: Fee rate must not be negative")
+	}
+
+	return CoinSelection{selector, FeeRate(feeRate)}
+}
+
+// submitTransaction adds a transaction to the mempool. Time-locked
+// transactions are printed instead so they can be submitted later.
+func submitTransaction(mempool Mempool, tx *Transaction) bool {
+	err := mempool.AcceptTransaction(tx)
+	if errors.Is(err, ErrTimeLocked) {
+		fmt.Println(err)
+		fmt.Println("Submit it with sendrawtransaction once the lock expires:")
+		fmt.Printf("%x\n", tx.Serialize())
+		return false
+	}
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return true
 }
\ No newline at end of file
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) sendMany(from string, payments []Payment, file string, stake int64, lockTime int64, sequence uint32, mine bool, coinSelect string, feeRate int) {
+	if !ValidateAddress(from) {
+		log.Panic("ERROR: Sender address is not valid")
+	}
+	selection := parseCoinSelection(coinSelect, feeRate)
+	if IsScriptAddress(from) {
+		log.Panic("ERROR: Batch sends from script addresses are not supported")
+	}
+	if file != "" {
+		filePayments, err := ReadPayments(file)
+		if err != nil {
+			log.Panic(err)
+		}
+		payments = append(payments, filePayments...)
+	}
+
+	bc := NewBlockchain()
+	UTXOSet := UTXOSet{bc}
+	mempool := Mempool{bc}
+	defer bc.db.Close()
+
+	tx := NewBatchTransaction(from, payments, lockTime, sequence, selection, &UTXOSet)
+
+	if !submitTransaction(mempool, tx) {
+		return
+	}
+	fmt.Printf("Paying %d recipients in transaction %x\n", len(payments), tx.ID)
+	if !mine {
+		fmt.Println("Transaction added to the mempool")
+		return
+	}
+
+	mempool.MineBlock(from, stake)
+	fmt.Println("Success!")
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) sendRawTransaction(txHex string) {
+	data, err := hex.DecodeString(txHex)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	var tx Transaction
+	if IsPartialTransaction(data) {
+		pt, err := DeserializePartialTransaction(data)
+		if err != nil {
+			log.Panic(err)
+		}
+		if !pt.IsComplete() {
+			log.Panic("ERROR: Transaction is not fully signed")
+		}
+		tx = pt.Tx
+	} else {
+		tx = DeserializeTransaction(data)
+	}
+
+	bc := NewBlockchain()
+	defer bc.db.Close()
+
+	if submitTransaction(Mempool{bc}, &tx) {
+		fmt.Printf("Transaction %x added to the mempool\n", tx.ID)
+	}
+}
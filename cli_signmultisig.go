@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) signMultiSig(txHex string, stake int64) {
+	data, err := hex.DecodeString(txHex)
+	if err != nil {
+		log.Panic(err)
+	}
+	tx := DeserializeTransaction(data)
+
+	wallets, err := NewWallets()
+	if err != nil {
+		log.Panic(err)
+	}
+
+	bc := NewBlockchain()
+	mempool := Mempool{bc}
+	defer bc.db.Close()
+
+	if bc.SignMultiSig(&tx, wallets) == 0 {
+		log.Panic("ERROR: No cosigner keys for this transaction in the wallet")
+	}
+
+	if tx.MissingSignatures() > 0 {
+		printPartialMultiSig(&tx)
+		return
+	}
+
+	script, err := DeserializeRedeemScript(tx.Vin[0].RedeemScript)
+	if err != nil {
+		log.Panic(err)
+	}
+	if !submitTransaction(mempool, &tx) {
+		return
+	}
+
+	mempool.MineBlock(fmt.Sprintf("%s", script.GetAddress()), stake)
+	fmt.Println("Success!")
+}
+
+// printPartialMultiSig prints a transaction that still needs cosigners
+func printPartialMultiSig(tx *Transaction) {
+	fmt.Printf("Transaction needs %d more signature(s), pass it to the next cosigner:\n", tx.MissingSignatures())
+	fmt.Printf("%x\n", tx.Serialize())
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) signRawTransaction(txHex, hashTypeName string) {
+	hashType, err := ParseSigHashType(hashTypeName)
+	if err != nil {
+		log.Panic(err)
+	}
+	data, err := hex.DecodeString(txHex)
+	if err != nil {
+		log.Panic(err)
+	}
+	pt, err := DeserializePartialTransaction(data)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	// Signing only needs the wallet file, not the blockchain
+	wallets, err := NewWallets()
+	if err != nil {
+		log.Panic(err)
+	}
+	if pt.Sign(wallets, hashType) == 0 {
+		log.Panic("ERROR: No keys for this transaction in the wallet")
+	}
+	// MuSig nonces are kept in the wallet between signing rounds
+	wallets.SaveToFile()
+
+	printPartialTransaction(pt)
+}
+
+// printPartialTransaction prints a partial transaction and whether it is
+// ready for sendrawtransaction
+func printPartialTransaction(pt *PartialTransaction) {
+	if pt.IsComplete() {
+		fmt.Println("Complete, submit it with sendrawtransaction:")
+	} else {
+		fmt.Println("Incomplete, pass it to the next signer or combine it with their copies:")
+	}
+	fmt.Printf("%x\n", pt.Serialize())
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) spvBalance(address, nodePath string) {
+	if !ValidateAddress(address) {
+		log.Panic("ERROR: Address is not valid")
+	}
+
+	node, err := NewLocalFullNode(nodePath)
+	if err != nil {
+		log.Panic(err)
+	}
+	defer node.Close()
+
+	client, err := NewLightClient()
+	if err != nil {
+		log.Panic(err)
+	}
+	defer client.Close()
+
+	balance, skipped, err := client.GetBalance(node, AddressToPubKeyHash(address))
+	if err != nil {
+		log.Panic(err)
+	}
+
+	fmt.Printf("Verified balance of '%s' including immature coinbase: %d\n", address, balance)
+	if skipped > 0 {
+		fmt.Printf("Skipped %d transactions in blocks without Merkle commitments\n", skipped)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) spvScan(address, nodePath string) {
+	if !ValidateAddress(address) {
+		log.Panic("ERROR: Address is not valid")
+	}
+
+	node, err := NewLocalFullNode(nodePath)
+	if err != nil {
+		log.Panic(err)
+	}
+	defer node.Close()
+
+	client, err := NewLightClient()
+	if err != nil {
+		log.Panic(err)
+	}
+	defer client.Close()
+
+	pubKeyHash := AddressToPubKeyHash(address)
+	txs, fetched, err := client.ScanFilters(node, pubKeyHash)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	for _, tx := range txs {
+		fmt.Printf("Transaction %x\n", tx.ID)
+	}
+	fmt.Printf("Fetched %d matching blocks\n", fetched)
+	fmt.Printf("Balance of '%s' including immature coinbase: %d\n", address, spvBalance(txs, pubKeyHash))
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) spvSync(nodePath string) {
+	node, err := NewLocalFullNode(nodePath)
+	if err != nil {
+		log.Panic(err)
+	}
+	defer node.Close()
+
+	client, err := NewLightClient()
+	if err != nil {
+		log.Panic(err)
+	}
+	defer client.Close()
+
+	added, rolledBack, err := client.Sync(node)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	tip := client.Tip()
+	if tip == nil {
+		fmt.Println("The node has no headers.")
+		return
+	}
+	if rolledBack > 0 {
+		fmt.Printf("Rolled back %d headers to the fork point at height %d\n", rolledBack, tip.Height-added)
+	}
+	fmt.Printf("Added %d headers, tip %x at height %d\n", added, tip.Hash, tip.Height)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) sweepChannel(id, address string, stake int64, mine bool) {
+	wallets, err := NewWallets()
+	if err != nil {
+		log.Panic(err)
+	}
+	channel, err := wallets.GetChannel(id)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	bc := NewBlockchain()
+	UTXOSet := UTXOSet{bc}
+	mempool := Mempool{bc}
+	defer bc.db.Close()
+
+	tx, err := channel.NewChannelSweep(address, wallets, &UTXOSet)
+	if err != nil {
+		log.Panic(err)
+	}
+	if !submitTransaction(mempool, tx) {
+		return
+	}
+
+	fmt.Printf("Claimed %d in transaction %x\n", tx.Vout[0].Value, tx.ID)
+	if !mine {
+		return
+	}
+
+	mempool.MineBlock(address, stake)
+	fmt.Println("Success!")
+}
@@ -0,0 +1,11 @@
+package main
+
+import "log"
+
+func (cli *CLI) transferName(from, name, to string, stake int64, mine bool, feeRate int) {
+	if !ValidateAddress(to) || IsScriptAddress(to) {
+		log.Panic("ERROR: New owner must be a wallet address")
+	}
+
+	cli.sendNameAction(from, NameAction{Op: NameTransfer, Name: name, To: AddressToPubKeyHash(to)}, stake, mine, feeRate)
+}
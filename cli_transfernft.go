@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) transferNFT(from, to, nftID string, stake int64, mine bool, feeRate int) {
+	if !ValidateAddress(from) || IsScriptAddress(from) {
+		log.Panic("ERROR: Sender must be a wallet address")
+	}
+	if !ValidateAddress(to) || IsScriptAddress(to) {
+		log.Panic("ERROR: Recipient must be a wallet address")
+	}
+	id, err := hex.DecodeString(nftID)
+	if err != nil || len(id) != nftIDLen {
+		log.Panic("ERROR: NFT ID is not valid")
+	}
+	selection := parseCoinSelection("bnb", feeRate)
+
+	bc := NewBlockchain()
+	UTXOSet := UTXOSet{bc}
+	mempool := Mempool{bc}
+	defer bc.db.Close()
+
+	tx := NewNFTTransfer(from, to, id, selection, &UTXOSet)
+	if !submitTransaction(mempool, tx) {
+		return
+	}
+	if !mine {
+		fmt.Printf("Transaction %x added to the mempool\n", tx.ID)
+		return
+	}
+
+	mempool.MineBlock(from, stake)
+	fmt.Println("Success!")
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+func (cli *CLI) verifyNotarization(file string) {
+	document, err := os.ReadFile(file)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	bc := NewBlockchain()
+	defer bc.db.Close()
+
+	block, tx, err := bc.FindData(NotarizationPayload(document))
+	if err != nil {
+		fmt.Printf("%s is not notarized\n", file)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Document:    %s\n", file)
+	fmt.Printf("Transaction: %x\n", tx.ID)
+	fmt.Printf("Block:       %x\n", block.Hash)
+	fmt.Printf("Height:      %d\n", block.Height)
+	fmt.Printf("Existed at:  %s\n", time.Unix(block.Timestamp, 0).UTC().Format(time.RFC3339))
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) voteParam(from, proposal string, approve bool, stake int64, mine bool, feeRate int) {
+	if !ValidateAddress(from) || IsScriptAddress(from) {
+		log.Panic("ERROR: Voter must be a wallet address")
+	}
+	id, err := hex.DecodeString(proposal)
+	if err != nil {
+		log.Panic(err)
+	}
+	selection := parseCoinSelection("bnb", feeRate)
+
+	bc := NewBlockchain()
+	UTXOSet := UTXOSet{bc}
+	mempool := Mempool{bc}
+	defer bc.db.Close()
+
+	action := GovernanceAction{Proposal: id, Approve: approve}
+	tx := NewGovernanceTransaction(from, action, selection, &UTXOSet)
+	if !submitTransaction(mempool, tx) {
+		return
+	}
+	if !mine {
+		fmt.Printf("Transaction %x added to the mempool\n", tx.ID)
+		return
+	}
+
+	mempool.MineBlock(from, stake)
+	fmt.Println("Success!")
+}
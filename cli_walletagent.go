@@ -0,0 +1,12 @@
+package main
+
+import (
+	"log"
+	"os"
+)
+
+func (cli *CLI) walletAgent() {
+	if err := RunWalletAgent(os.Stdin, os.Stdout); err != nil {
+		log.Panic(err)
+	}
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+func (cli *CLI) walletLock() {
+	wallets, err := NewWallets()
+	if err != nil {
+		log.Panic(err)
+	}
+	if !wallets.IsEncrypted() {
+		log.Panic("ERROR: Wallet is not encrypted")
+	}
+	if err := wallets.Lock(); err != nil {
+		log.Panic(err)
+	}
+
+	fmt.Println("Wallet locked")
+}
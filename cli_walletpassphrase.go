@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+func (cli *CLI) walletPassphrase(passphrase string, timeout int) {
+	passphrase = readPassphrase(passphrase)
+
+	wallets, err := NewWallets()
+	if err != nil {
+		log.Panic(err)
+	}
+	if err := wallets.Unlock(passphrase, time.Duration(timeout)*time.Second); err != nil {
+		log.Panic(err)
+	}
+
+	fmt.Printf("Wallet unlocked for %d seconds\n", timeout)
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// Rough serialized sizes used to estimate the fee of a transaction before
+// it is built
+const estimatedTxOverhead = 100
+const estimatedInputSize = 150
+const estimatedOutputSize = 50
+
+// maxBnBTries bounds the branch-and-bound search
+const maxBnBTries = 100000
+
+// ErrInsufficientFunds is returned when the coins cannot pay the amount
+// and the fee of spending them
+var ErrInsufficientFunds = errors.New("not enough funds")
+
+// Coin is a spendable output considered by coin selection
+type Coin struct {
+	TxID  string
+	Index int
+	Value int
+}
+
+// FeeRate is the fee paid per 1000 bytes of transaction
+type FeeRate int
+
+// Fee returns the fee of a transaction with the given number of inputs and
+// outputs, rounded up
+func (r FeeRate) Fee(inputs, outputs int) int {
+	size := estimatedTxOverhead + inputs*estimatedInputSize + outputs*estimatedOutputSize
+
+	return r.FeeForSize(size)
+}
+
+// FeeForSize returns the fee of a transaction of size bytes, rounded up
+func (r FeeRate) FeeForSize(size int) int {
+	return (int(r)*size + 999) / 1000
+}
+
+// CoinSelector picks the coins funding a transaction. The selected coins
+// must cover amount plus the fee of a transaction spending them into
+// outputs payments, any excess goes to a change output when it pays for
+// itself and to the fee otherwise.
+type CoinSelector interface {
+	Select(coins []Coin, amount, outputs int, feeRate FeeRate) ([]Coin, error)
+}
+
+// CoinSelection is a strategy together with the fee rate it pays
+type CoinSelection struct {
+	Selector CoinSelector
+	FeeRate  FeeRate
+}
+
+// DefaultCoinSelection is used by transaction builders without a choice
+var DefaultCoinSelection = CoinSelection{BranchAndBoundSelector{}, 0}
+
+var coinSelectors = map[string]CoinSelector{
+	"first":    FirstFitSelector{},
+	"largest":  LargestFirstSelector{},
+	"smallest": SmallestFirstSelector{},
+	"bnb":      BranchAndBoundSelector{},
+	"random":   RandomSelector{},
+}
+
+// ParseCoinSelector returns the selector registered under name
+func ParseCoinSelector(name string) (CoinSelector, error) {
+	selector, ok := coinSelectors[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown coin selection %q", name)
+	}
+
+	return selector, nil
+}
+
+// accumulate takes coins in order until they cover amount and fee
+func accumulate(coins []Coin, amount, outputs int, feeRate FeeRate) ([]Coin, error) {
+	var selected []Coin
+	total := 0
+
+	for _, coin := range coins {
+		selected = append(selected, coin)
+		total += coin.Value
+		if total >= amount+feeRate.Fee(len(selected), outputs) {
+			return selected, nil
+		}
+	}
+
+	return nil, ErrInsufficientFunds
+}
+
+// FirstFitSelector takes coins in database order, the original behaviour
+type FirstFitSelector struct{}
+
+// Select implements CoinSelector
+func (FirstFitSelector) Select(coins []Coin, amount, outputs int, feeRate FeeRate) ([]Coin, error) {
+	return accumulate(coins, amount, outputs, feeRate)
+}
+
+// LargestFirstSelector spends the fewest coins, keeping fees low
+type LargestFirstSelector struct{}
+
+// Select implements CoinSelector
+func (LargestFirstSelector) Select(coins []Coin, amount, outputs int, feeRate FeeRate) ([]Coin, error) {
+	sorted := append([]Coin{}, coins...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Value > sorted[j].Value })
+
+	return accumulate(sorted, amount, outputs, feeRate)
+}
+
+// SmallestFirstSelector consolidates small coins at the cost of higher fees
+type SmallestFirstSelector struct{}
+
+// Select implements CoinSelector
+func (SmallestFirstSelector) Select(coins []Coin, amount, outputs int, feeRate FeeRate) ([]Coin, error) {
+	sorted := append([]Coin{}, coins...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Value < sorted[j].Value })
+
+	return accumulate(sorted, amount, outputs, feeRate)
+}
+
+// RandomSelector takes coins in random order so that spends do not reveal
+// which coins belong together
+type RandomSelector struct{}
+
+// Select implements CoinSelector
+func (RandomSelector) Select(coins []Coin, amount, outputs int, feeRate FeeRate) ([]Coin, error) {
+	shuffled := append([]Coin{}, coins...)
+	rand.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+	return accumulate(shuffled, amount, outputs, feeRate)
+}
+
+// BranchAndBoundSelector searches for a set of coins that pays amount and
+// fee without change, within the cost of adding a change output. It falls
+// back to largest first when no such set exists.
+type BranchAndBoundSelector struct{}
+
+// Select implements CoinSelector
+func (BranchAndBoundSelector) Select(coins []Coin, amount, outputs int, feeRate FeeRate) ([]Coin, error) {
+	// Coins worth less than the fee of spending them never help
+	inputFee := feeRate.Fee(1, 0) - feeRate.Fee(0, 0)
+	var sorted []Coin
+	available := 0
+	for _, coin := range coins {
+		if coin.Value > inputFee {
+			sorted = append(sorted, coin)
+			available += coin.Value
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Value > sorted[j].Value })
+
+	var best []int
+	bestExcess := -1
+	var current []int
+	tries := 0
+
+	var search func(depth, total, remaining int)
+	search = func(depth, total, remaining int) {
+		tries++
+		if tries > maxBnBTries || bestExcess == 0 {
+			return
+		}
+
+		if len(current) > 0 {
+			excess := total - amount - feeRate.Fee(len(current), outputs)
+			changeCost := feeRate.Fee(len(current), outputs+1) - feeRate.Fee(len(current), outputs)
+			if excess > changeCost {
+				return
+			}
+			if excess >= 0 {
+				if bestExcess < 0 || excess < bestExcess {
+					best = append([]int{}, current...)
+					bestExcess = excess
+				}
+				return
+			}
+		}
+		if depth == len(sorted) || total+remaining < amount {
+			return
+		}
+
+		value := sorted[depth].Value
+		current = append(current, depth)
+		search(depth+1, total+value, remaining-value)
+		current = current[:len(current)-1]
+		search(depth+1, total, remaining-value)
+	}
+	search(0, 0, available)
+
+	if bestExcess < 0 {
+		return LargestFirstSelector{}.Select(coins, amount, outputs, feeRate)
+	}
+
+	var selected []Coin
+	for _, i := range best {
+		selected = append(selected, sorted[i])
+	}
+
+	return selected, nil
+}
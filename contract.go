@@ -0,0 +1,369 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// Contract code, storage, the undo records of each block and the receipt
+// of each contract transaction live in the blockchain database under
+// their own prefixes
+const contractBucket = "contract"
+const contractStateBucket = "contractstate"
+const contractUndoBucket = "contractundo"
+const contractReceiptBucket = "contractreceipt"
+
+const maxContractCodeSize = 24576
+const maxContractArgs = 16
+
+// contractDeployGas is charged per byte of deployed code
+const contractDeployGas = 1
+
+// ContractCall deploys a contract when Code is set and calls the contract
+// Contract otherwise. It rides on the first input of a transaction, whose
+// fee must cover GasLimit at GasPrice. The whole fee is spent, however
+// little gas the execution uses.
+type ContractCall struct {
+	Code     []byte
+	Contract []byte
+	Args     []int64
+	GasLimit int
+	GasPrice int
+}
+
+// NewContractID derives the ID of a contract deployed by the input
+// spending the output txID:outIdx
+func NewContractID(txID []byte, outIdx int) []byte {
+	data := append([]byte("contract"), txID...)
+	data = binary.BigEndian.AppendUint64(data, uint64(outIdx))
+	hash := sha256.Sum256(data)
+
+	return hash[:]
+}
+
+// GasFee returns the fee the call has to pay
+func (cc ContractCall) GasFee() int {
+	return cc.GasLimit * cc.GasPrice
+}
+
+// check enforces the contract call rules that need no chain state
+func (cc ContractCall) check() error {
+	if (len(cc.Code) > 0) == (len(cc.Contract) > 0) {
+		return errors.New("a contract transaction either deploys code or calls a contract")
+	}
+	if len(cc.Code) > maxContractCodeSize {
+		return fmt.Errorf("contract code has %d bytes, the limit is %d", len(cc.Code), maxContractCodeSize)
+	}
+	if len(cc.Code) > 0 {
+		if _, err := instructionStarts(cc.Code); err != nil {
+			return err
+		}
+	}
+	if len(cc.Contract) > 0 && len(cc.Contract) != sha256.Size {
+		return errors.New("contract ID is not valid")
+	}
+	if len(cc.Args) > maxContractArgs {
+		return fmt.Errorf("at most %d contract arguments are allowed", maxContractArgs)
+	}
+	if cc.GasLimit <= 0 || cc.GasLimit > chainParams().MaxContractGas {
+		return fmt.Errorf("gas limit must be between 1 and %d", chainParams().MaxContractGas)
+	}
+	if cc.GasPrice < 0 {
+		return errors.New("gas price must not be negative")
+	}
+
+	return nil
+}
+
+// CheckContract checks the contract call of a transaction and that its fee
+// pays for the gas
+func (bc *Blockchain) CheckContract(tx *Transaction, parents map[string]*Transaction) error {
+	for i, vin := range tx.Vin {
+		if vin.Contract != nil && (i > 0 || tx.IsCoinbase()) {
+			return fmt.Errorf("transaction %x carries a contract call on input %d", tx.ID, i)
+		}
+	}
+	if tx.IsCoinbase() || tx.Vin[0].Contract == nil {
+		return nil
+	}
+
+	call := tx.Vin[0].Contract
+	if err := call.check(); err != nil {
+		return fmt.Errorf("transaction %x: %s", tx.ID, err)
+	}
+
+	fee, err := bc.TransactionFee(tx, parents)
+	if err != nil {
+		return err
+	}
+	if fee < call.GasFee() {
+		return fmt.Errorf("transaction %x pays %d, its gas costs %d", tx.ID, fee, call.GasFee())
+	}
+
+	return nil
+}
+
+// ContractReceipt records the outcome of a contract transaction
+type ContractReceipt struct {
+	Contract []byte
+	ExecutionResult
+}
+
+// Serialize serializes the receipt
+func (r ContractReceipt) Serialize() []byte {
+	var encoded bytes.Buffer
+
+	enc := gob.NewEncoder(&encoded)
+	err := enc.Encode(r)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return encoded.Bytes()
+}
+
+// stateUndo is the value a key had before a block changed it, nil when it
+// did not exist
+type stateUndo struct {
+	Key   []byte
+	Value []byte
+}
+
+// stateUndoLog lists the first change of each key in a block
+type stateUndoLog []stateUndo
+
+// Serialize serializes the undo log
+func (ul stateUndoLog) Serialize() []byte {
+	var encoded bytes.Buffer
+
+	enc := gob.NewEncoder(&encoded)
+	err := enc.Encode(ul)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return encoded.Bytes()
+}
+
+// chainState collects the changes a block makes to state kept in the
+// database, like contract storage, on top of the database
+type chainState struct {
+	db      *leveldb.DB
+	changes map[string][]byte
+	undo    stateUndoLog
+}
+
+func newChainState(db *leveldb.DB) *chainState {
+	return &chainState{db, make(map[string][]byte), nil}
+}
+
+func (s *chainState) get(key []byte) []byte {
+	if value, ok := s.changes[string(key)]; ok {
+		return value
+	}
+	value, err := s.db.Get(key, nil)
+	if err != nil {
+		return nil
+	}
+
+	return value
+}
+
+func (s *chainState) put(key, value []byte) {
+	if _, ok := s.changes[string(key)]; !ok {
+		s.undo = append(s.undo, stateUndo{key, s.get(key)})
+	}
+	s.changes[string(key)] = value
+}
+
+// write adds the changes to batch, with the undo log under undoKey
+func (s *chainState) write(batch *leveldb.Batch, undoKey []byte) {
+	if len(s.undo) == 0 {
+		return
+	}
+
+	for key, value := range s.changes {
+		if value == nil {
+			batch.Delete([]byte(key))
+		} else {
+			batch.Put([]byte(key), value)
+		}
+	}
+	batch.Put(undoKey, s.undo.Serialize())
+}
+
+// restoreState adds undoing the changes logged under undoKey to batch
+func (bc *Blockchain) restoreState(batch *leveldb.Batch, undoKey []byte) {
+	data, err := bc.db.Get(undoKey, nil)
+	if err != nil {
+		return
+	}
+	var undo stateUndoLog
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&undo); err != nil {
+		log.Panic(err)
+	}
+
+	for i := len(undo) - 1; i >= 0; i-- {
+		if undo[i].Value == nil {
+			batch.Delete(undo[i].Key)
+		} else {
+			batch.Put(undo[i].Key, undo[i].Value)
+		}
+	}
+	batch.Delete(undoKey)
+}
+
+// stateKey is the database key of id in bucket
+func stateKey(bucket string, id []byte) []byte {
+	return append([]byte(bucket+"_"), id...)
+}
+
+// contractStorage is the storage of one contract in a chainState, a value
+// of zero deletes its key
+type contractStorage struct {
+	state *chainState
+	id    []byte
+}
+
+func (cs contractStorage) key(key int64) []byte {
+	return binary.BigEndian.AppendUint64(stateKey(contractStateBucket, cs.id), uint64(key))
+}
+
+func (cs contractStorage) Load(key int64) int64 {
+	value := cs.state.get(cs.key(key))
+	if len(value) != 8 {
+		return 0
+	}
+
+	return int64(binary.BigEndian.Uint64(value))
+}
+
+func (cs contractStorage) Store(key, value int64) {
+	if value == 0 {
+		cs.state.put(cs.key(key), nil)
+		return
+	}
+	cs.state.put(cs.key(key), binary.BigEndian.AppendUint64(nil, uint64(value)))
+}
+
+// runContract deploys or calls the contract of a transaction
+func (s *chainState) runContract(tx *Transaction) ContractReceipt {
+	call := tx.Vin[0].Contract
+
+	if len(call.Code) > 0 {
+		id := NewContractID(tx.Vin[0].Txid, tx.Vin[0].Vout)
+		gas := len(call.Code) * contractDeployGas
+		if gas > call.GasLimit {
+			return ContractReceipt{id, ExecutionResult{false, call.GasLimit, 0, ErrOutOfGas.Error()}}
+		}
+		s.put(stateKey(contractBucket, id), call.Code)
+
+		return ContractReceipt{id, ExecutionResult{true, gas, 0, ""}}
+	}
+
+	code := s.get(stateKey(contractBucket, call.Contract))
+	if code == nil {
+		return ContractReceipt{call.Contract, ExecutionResult{false, 0, 0, "contract does not exist"}}
+	}
+
+	result := RunContract(code, call.Args, call.GasLimit, contractStorage{s, call.Contract})
+	return ContractReceipt{call.Contract, result}
+}
+
+// executeContracts runs the contract transactions of a block in order and
+// adds their storage changes, receipts and the undo record of the block to
+// batch
+func (bc *Blockchain) executeContracts(batch *leveldb.Batch, block *Block) {
+	state := newChainState(bc.db)
+
+	for _, tx := range block.Transactions {
+		if tx.IsCoinbase() || tx.Vin[0].Contract == nil {
+			continue
+		}
+		receipt := state.runContract(tx)
+		batch.Put(stateKey(contractReceiptBucket, tx.ID), receipt.Serialize())
+	}
+	state.write(batch, stateKey(contractUndoBucket, block.Hash))
+}
+
+// disconnectContracts adds restoring the contract storage from before the
+// block and dropping its receipts to batch
+func (bc *Blockchain) disconnectContracts(batch *leveldb.Batch, block *Block) {
+	for _, tx := range block.Transactions {
+		batch.Delete(stateKey(contractReceiptBucket, tx.ID))
+	}
+	bc.restoreState(batch, stateKey(contractUndoBucket, block.Hash))
+}
+
+// GetContractReceipt returns the receipt of a mined contract transaction
+func (bc *Blockchain) GetContractReceipt(txID []byte) (*ContractReceipt, error) {
+	data, err := bc.db.Get(stateKey(contractReceiptBucket, txID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("no contract receipt for transaction %x", txID)
+	}
+
+	var receipt ContractReceipt
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&receipt); err != nil {
+		return nil, err
+	}
+
+	return &receipt, nil
+}
+
+// GetContract returns the code and the non-zero storage of a contract
+func (bc *Blockchain) GetContract(id []byte) ([]byte, map[int64]int64, error) {
+	code, err := bc.db.Get(stateKey(contractBucket, id), nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("contract %x does not exist", id)
+	}
+
+	storage := make(map[int64]int64)
+	prefix := stateKey(contractStateBucket, id)
+	iter := bc.db.NewIterator(util.BytesPrefix(prefix), nil)
+	for iter.Next() {
+		key := iter.Key()[len(prefix):]
+		storage[int64(binary.BigEndian.Uint64(key))] = int64(binary.BigEndian.Uint64(iter.Value()))
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return nil, nil, err
+	}
+
+	return code, storage, nil
+}
+
+// NewContractTransaction creates a transaction from a wallet address
+// carrying a contract deployment or call, its fee pays for the gas
+func NewContractTransaction(from string, call ContractCall, selection CoinSelection, UTXOSet *UTXOSet) *Transaction {
+	if err := call.check(); err != nil {
+		log.Panic(err)
+	}
+	wallets, err := NewWallets()
+	if err != nil {
+		log.Panic(err)
+	}
+	wallet := wallets.GetWallet(from)
+	pubKeyHash := AddressToPubKeyHash(from)
+
+	inputs, prevOutputs, value := fundFee(nil, nil, -call.GasFee(), 1, pubKeyHash, selection, UTXOSet)
+	inputs[0].Contract = &call
+
+	var outputs []TXOutput
+	if change := value - selection.FeeRate.Fee(len(inputs), 1); change > 0 {
+		outputs = append(outputs, *NewTXOutput(change, from))
+	}
+
+	tx := Transaction{nil, inputs, outputs, 0}
+	tx.ID = tx.Hash()
+	tx.SignPrevOutputs(wallet.PrivateKey, prevOutputs, SigHashAll)
+
+	return &tx
+}
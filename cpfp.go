@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/hex"
+	"log"
+)
+
+// NewChildPaysForParent spends the outputs of a pending transaction that
+// belong to wallet keys into one output paying to, with a fee that lifts the
+// parent package, the parent and its pending ancestors paying packageFee
+// over packageSize bytes, to feeRate once the child joins it. Block
+// templates rank the parent by that package rate, so the child pulls it in.
+// An empty to pays back to the key of the first spent output.
+func NewChildPaysForParent(parent *Transaction, packageFee, packageSize int, feeRate FeeRate, wallets *Wallets, to string) *Transaction {
+	var inputs []TXInput
+	var prevOutputs []TXOutput
+	signers := make(map[string]*Wallet)
+	value := 0
+
+	for i, out := range parent.Vout {
+		// Asset and NFT outputs would need a matching output in the child
+		if out.IsData() || out.IsAsset() || out.IsNFT() {
+			continue
+		}
+		wallet := wallets.FindByPubKeyHash(out.PubKeyHash)
+		if wallet == nil {
+			continue
+		}
+
+		signers[hex.EncodeToString(out.PubKeyHash)] = wallet
+		inputs = append(inputs, TXInput{Txid: parent.ID, Vout: i, Sequence: sequenceReplaceable})
+		prevOutputs = append(prevOutputs, out)
+		value += out.Value
+	}
+	if len(inputs) == 0 {
+		log.Panic("ERROR: Transaction has no outputs belonging to the wallet")
+	}
+	toPubKeyHash := prevOutputs[0].PubKeyHash
+	if to != "" {
+		toPubKeyHash = NewTXOutput(0, to).PubKeyHash
+	}
+
+	// The child's size depends on its output value, repeat until the fee
+	// settles
+	var child Transaction
+	childFee := 0
+	for {
+		child = Transaction{nil, inputs, []TXOutput{{value - childFee, toPubKeyHash, nil, nil, nil}}, 0}
+		child.ID = child.Hash()
+		for _, wallet := range signers {
+			child.SignPrevOutputs(wallet.PrivateKey, prevOutputs, SigHashAll)
+		}
+
+		fee := feeRate.FeeForSize(packageSize+child.Size()) - packageFee
+		if fee <= childFee {
+			break
+		}
+		childFee = fee
+	}
+
+	if childFee <= 0 {
+		log.Panic("ERROR: Transaction already pays the fee rate")
+	}
+	if childFee >= value {
+		log.Panicf("ERROR: Outputs worth %d cannot pay a child fee of %d", value, childFee)
+	}
+
+	return &child
+}
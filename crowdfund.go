@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/hex"
+	"log"
+)
+
+// NewCrowdfundTransaction creates a transaction without inputs paying goal
+// to address. Contributors add inputs signed with ALL|ANYONECANPAY until
+// they cover the goal.
+func NewCrowdfundTransaction(address string, goal int) *Transaction {
+	tx := Transaction{nil, nil, []TXOutput{*NewTXOutput(goal, address)}, 0}
+	tx.ID = tx.Hash()
+
+	return &tx
+}
+
+// AddContribution adds inputs worth exactly amount from the wallet of from
+// to tx and signs only those inputs. When the coins of from do not add up
+// to amount, a payment of amount back to from splits it off first; that
+// payment is returned and has to be mined before or along with tx.
+func (u UTXOSet) AddContribution(tx *Transaction, from string, amount int, hashType byte) *Transaction {
+	wallets, err := NewWallets()
+	if err != nil {
+		log.Panic(err)
+	}
+	wallet := wallets.GetWallet(from)
+	pubKeyHash := HashPubKey(wallet.PublicKey)
+	acc, validOutputs := u.FindSpendableOutputs(pubKeyHash, amount)
+
+	if acc < amount {
+		log.Panic("ERROR: Not enough funds")
+	}
+
+	parents := Mempool{u.Blockchain}.pool()
+	var split *Transaction
+	if acc > amount {
+		// The split pays amount in its first output, the rest is change
+		split = NewUTXOTransaction(from, from, amount, 0, 0, &u)
+		parents[hex.EncodeToString(split.ID)] = split
+		tx.Vin = append(tx.Vin, TXInput{Txid: split.ID, Vout: 0, PubKey: wallet.PublicKey})
+	} else {
+		for txid, outs := range validOutputs {
+			txID, err := hex.DecodeString(txid)
+			if err != nil {
+				log.Panic(err)
+			}
+
+			for _, out := range outs {
+				input := TXInput{Txid: txID, Vout: out, PubKey: wallet.PublicKey}
+				tx.Vin = append(tx.Vin, input)
+			}
+		}
+	}
+
+	// Earlier contributions may spend pending splits as well
+	prevOutputs := make([]TXOutput, len(tx.Vin))
+	for i, vin := range tx.Vin {
+		out, err := u.Blockchain.FindSpentOutput(vin.Txid, vin.Vout, parents)
+		if err != nil {
+			log.Panic(err)
+		}
+		prevOutputs[i] = *out
+	}
+
+	tx.ID = tx.Hash()
+	tx.SignPrevOutputs(wallet.PrivateKey, prevOutputs, hashType)
+
+	return split
+}
+
+// InputValue returns the value of the outputs the inputs spend, unspent
+// outputs or outputs of pending transactions
+func (u UTXOSet) InputValue(tx *Transaction) int {
+	value, err := u.Blockchain.InputValue(tx, Mempool{u.Blockchain}.pool())
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return value
+}
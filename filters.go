@@ -0,0 +1,318 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"math/bits"
+	"sort"
+
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// Block filters are Golomb-coded sets: the items are hashed into a range of
+// N*gcsM values, sorted, and the differences between neighbours stored as
+// Golomb-Rice codes with gcsP remainder bits. A query matches a missing
+// item with a probability of about 1/gcsM.
+const (
+	gcsP = 19
+	gcsM = 784931
+)
+
+const filterBucket = "filter"
+const filterHeaderBucket = "filterheader"
+
+// GCSFilter is a compact filter over the items of a block, hashed with a key
+// taken from the block hash so filters of different blocks are independent
+type GCSFilter struct {
+	N    int
+	Key  []byte
+	Data []byte
+}
+
+func filterKey(blockHash []byte) []byte {
+	return blockHash[:16]
+}
+
+// hashToRange maps an item to [0, N*gcsM)
+func (f GCSFilter) hashToRange(item []byte) uint64 {
+	hash := sha256.Sum256(append(append([]byte{}, f.Key...), item...))
+	hi, _ := bits.Mul64(binary.BigEndian.Uint64(hash[:8]), uint64(f.N)*gcsM)
+
+	return hi
+}
+
+// sortedHashes returns the distinct range hashes of the items in order
+func (f GCSFilter) sortedHashes(items [][]byte) []uint64 {
+	seen := make(map[uint64]bool)
+	var hashes []uint64
+
+	for _, item := range items {
+		hash := f.hashToRange(item)
+		if !seen[hash] {
+			seen[hash] = true
+			hashes = append(hashes, hash)
+		}
+	}
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i] < hashes[j] })
+
+	return hashes
+}
+
+// NewGCSFilter builds the filter over the distinct items
+func NewGCSFilter(key []byte, items [][]byte) *GCSFilter {
+	distinct := make(map[string][]byte)
+	for _, item := range items {
+		distinct[string(item)] = item
+	}
+
+	filter := &GCSFilter{N: len(distinct), Key: key}
+	if filter.N == 0 {
+		return filter
+	}
+
+	var unique [][]byte
+	for _, item := range distinct {
+		unique = append(unique, item)
+	}
+
+	var w bitWriter
+	var last uint64
+	for _, hash := range filter.sortedHashes(unique) {
+		delta := hash - last
+		last = hash
+
+		for q := delta >> gcsP; q > 0; q-- {
+			w.writeBit(1)
+		}
+		w.writeBit(0)
+		w.writeBits(delta, gcsP)
+	}
+	filter.Data = w.bytes
+
+	return filter
+}
+
+// MatchAny reports whether any of the items may be in the filter
+func (f GCSFilter) MatchAny(items [][]byte) bool {
+	if f.N == 0 || len(items) == 0 {
+		return false
+	}
+
+	queries := f.sortedHashes(items)
+	r := bitReader{data: f.Data}
+	var value uint64
+	for i := 0; i < f.N; i++ {
+		delta, err := r.readGolomb()
+		if err != nil {
+			return false
+		}
+		value += delta
+
+		for len(queries) > 0 && queries[0] < value {
+			queries = queries[1:]
+		}
+		if len(queries) == 0 {
+			return false
+		}
+		if queries[0] == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Serialize returns the number of items followed by the coded set, the key
+// is known from the block hash
+func (f GCSFilter) Serialize() []byte {
+	data := make([]byte, 4, 4+len(f.Data))
+	binary.BigEndian.PutUint32(data, uint32(f.N))
+
+	return append(data, f.Data...)
+}
+
+// DeserializeGCSFilter decodes the filter of the block blockHash
+func DeserializeGCSFilter(data, blockHash []byte) (*GCSFilter, error) {
+	if len(data) < 4 {
+		return nil, errors.New("truncated block filter")
+	}
+
+	return &GCSFilter{int(binary.BigEndian.Uint32(data)), filterKey(blockHash), data[4:]}, nil
+}
+
+// outpointFilterItem is the filter item for a spent output
+func outpointFilterItem(txID []byte, outIdx int) []byte {
+	item := make([]byte, len(txID)+4)
+	copy(item, txID)
+	binary.BigEndian.PutUint32(item[len(txID):], uint32(outIdx))
+
+	return item
+}
+
+// NewBlockFilter builds the filter over the output scripts of a block and
+// the outpoints its transactions spend. Data outputs are never spendable
+// and left out.
+func NewBlockFilter(block *Block) *GCSFilter {
+	var items [][]byte
+
+	for _, tx := range block.Transactions {
+		for _, out := range tx.Vout {
+			if !out.IsData() {
+				items = append(items, out.PubKeyHash)
+			}
+		}
+		if tx.IsCoinbase() {
+			continue
+		}
+		for _, vin := range tx.Vin {
+			items = append(items, outpointFilterItem(vin.Txid, vin.Vout))
+		}
+	}
+
+	return NewGCSFilter(filterKey(block.Hash), items)
+}
+
+// FilterHeader chains a filter to the header of the previous block's
+// filter, the genesis filter follows a header of zeros
+func FilterHeader(filter *GCSFilter, prevHeader []byte) []byte {
+	filterHash := sha256.Sum256(filter.Serialize())
+	header := sha256.Sum256(append(filterHash[:], prevHeader...))
+
+	return header[:]
+}
+
+func filterDBKey(bucket string, blockHash []byte) []byte {
+	return append([]byte(bucket+"_"), blockHash...)
+}
+
+// indexFilter adds the filter and filter header of a block to batch. Chains
+// created before the filter index have no header to extend until
+// reindexfilters builds it, their blocks are skipped.
+func (bc *Blockchain) indexFilter(batch *leveldb.Batch, block *Block) {
+	prevHeader := make([]byte, sha256.Size)
+	if len(block.PrevBlockHash) > 0 {
+		var err error
+		prevHeader, err = bc.GetFilterHeader(block.PrevBlockHash)
+		if err != nil {
+			return
+		}
+	}
+
+	filter := NewBlockFilter(block)
+	batch.Put(filterDBKey(filterBucket, block.Hash), filter.Serialize())
+	batch.Put(filterDBKey(filterHeaderBucket, block.Hash), FilterHeader(filter, prevHeader))
+}
+
+// GetFilter returns the filter of a block from the filter index
+func (bc *Blockchain) GetFilter(blockHash []byte) (*GCSFilter, error) {
+	data, err := bc.db.Get(filterDBKey(filterBucket, blockHash), nil)
+	if err != nil {
+		return nil, fmt.Errorf("no filter for block %x, run reindexfilters", blockHash)
+	}
+
+	return DeserializeGCSFilter(data, blockHash)
+}
+
+// GetFilterHeader returns the filter header of a block from the filter index
+func (bc *Blockchain) GetFilterHeader(blockHash []byte) ([]byte, error) {
+	header, err := bc.db.Get(filterDBKey(filterHeaderBucket, blockHash), nil)
+	if err != nil {
+		return nil, fmt.Errorf("no filter header for block %x, run reindexfilters", blockHash)
+	}
+
+	return header, nil
+}
+
+// ReindexFilters rebuilds the filter index from the genesis block and
+// returns the number of blocks indexed
+func (bc *Blockchain) ReindexFilters() int {
+	var blocks []*Block
+
+	bci := bc.Iterator()
+	for {
+		block := bci.Next()
+		blocks = append([]*Block{block}, blocks...)
+
+		if len(block.PrevBlockHash) == 0 {
+			break
+		}
+	}
+
+	batch := new(leveldb.Batch)
+	prevHeader := make([]byte, sha256.Size)
+	for _, block := range blocks {
+		filter := NewBlockFilter(block)
+		prevHeader = FilterHeader(filter, prevHeader)
+		batch.Put(filterDBKey(filterBucket, block.Hash), filter.Serialize())
+		batch.Put(filterDBKey(filterHeaderBucket, block.Hash), prevHeader)
+	}
+
+	if err := bc.db.Write(batch, nil); err != nil {
+		log.Panic(err)
+	}
+
+	return len(blocks)
+}
+
+type bitWriter struct {
+	bytes []byte
+	used  uint
+}
+
+func (w *bitWriter) writeBit(bit byte) {
+	if w.used == 0 {
+		w.bytes = append(w.bytes, 0)
+		w.used = 8
+	}
+	w.used--
+	w.bytes[len(w.bytes)-1] |= bit << w.used
+}
+
+func (w *bitWriter) writeBits(value uint64, n uint) {
+	for i := n; i > 0; i-- {
+		w.writeBit(byte(value>>(i-1)) & 1)
+	}
+}
+
+type bitReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *bitReader) readBit() (uint64, error) {
+	if r.pos >= len(r.data)*8 {
+		return 0, errors.New("truncated block filter")
+	}
+	bit := r.data[r.pos/8] >> (7 - uint(r.pos%8)) & 1
+	r.pos++
+
+	return uint64(bit), nil
+}
+
+func (r *bitReader) readGolomb() (uint64, error) {
+	var quotient uint64
+	for {
+		bit, err := r.readBit()
+		if err != nil {
+			return 0, err
+		}
+		if bit == 0 {
+			break
+		}
+		quotient++
+	}
+
+	remainder := uint64(0)
+	for i := 0; i < gcsP; i++ {
+		bit, err := r.readBit()
+		if err != nil {
+			return 0, err
+		}
+		remainder = remainder<<1 | bit
+	}
+
+	return quotient<<gcsP | remainder, nil
+}
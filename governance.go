@@ -0,0 +1,384 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// Proposals, the votes cast on them, the scheduled parameter changes and
+// the undo records of each block live in the blockchain database under
+// their own prefixes
+const proposalBucket = "proposal"
+const voteBucket = "vote"
+const paramChangeBucket = "paramchange"
+const governanceUndoBucket = "governanceundo"
+
+// governanceVotingPeriod is the number of blocks a proposal takes votes
+// for, starting with the block that includes it
+const governanceVotingPeriod = 10
+
+// governanceActivationDelay is the least number of blocks between the end
+// of the voting period and the activation of a change
+const governanceActivationDelay = 5
+
+// governanceQuorum is the percentage of the coin supply that has to vote
+// and governanceThreshold the percentage of the voting stake that has to
+// approve for a proposal to pass
+const governanceQuorum = 30
+const governanceThreshold = 67
+
+// Proposal states
+const (
+	ProposalVoting = iota
+	ProposalPassed
+	ProposalRejected
+)
+
+// GovernanceAction proposes to change the chain parameter Param to Value
+// from the block at height Activation when Proposal is empty, and votes on
+// the proposal with ID Proposal otherwise. It rides on the first input of
+// a transaction, which has to be signed with a key: that key's address
+// makes the proposal or casts the vote.
+type GovernanceAction struct {
+	Param      string
+	Value      int
+	Activation int
+	Proposal   []byte
+	Approve    bool
+}
+
+// check enforces the governance rules that need no chain state
+func (ga GovernanceAction) check() error {
+	if len(ga.Proposal) == 0 {
+		_, ok := governableParams[ga.Param]
+		if !ok {
+			return fmt.Errorf("unknown chain parameter %s", ga.Param)
+		}
+		return nil
+	}
+
+	if ga.Param != "" || ga.Value != 0 || ga.Activation != 0 {
+		return errors.New("a governance transaction either proposes a change or votes")
+	}
+	if len(ga.Proposal) != sha256.Size {
+		return errors.New("proposal ID is not valid")
+	}
+
+	return nil
+}
+
+// Proposal is a proposed parameter change and, once its voting period is
+// over, the outcome of the vote. Yes and No are the balances of the
+// approving and rejecting voters and Supply all coins when it was tallied.
+type Proposal struct {
+	ID         []byte
+	Param      string
+	Value      int
+	Proposer   []byte
+	Start      int
+	End        int
+	Activation int
+	Status     int
+	Yes        int
+	No         int
+	Supply     int
+}
+
+// Serialize serializes the proposal
+func (p Proposal) Serialize() []byte {
+	var encoded bytes.Buffer
+
+	enc := gob.NewEncoder(&encoded)
+	err := enc.Encode(p)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return encoded.Bytes()
+}
+
+// DeserializeProposal deserializes a proposal
+func DeserializeProposal(data []byte) (*Proposal, error) {
+	var proposal Proposal
+
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&proposal); err != nil {
+		return nil, err
+	}
+
+	return &proposal, nil
+}
+
+// StatusString describes the state of the proposal
+func (p Proposal) StatusString() string {
+	switch p.Status {
+	case ProposalPassed:
+		return "passed"
+	case ProposalRejected:
+		return "rejected"
+	}
+
+	return "voting"
+}
+
+func voteKey(proposal, voter []byte) []byte {
+	return append(stateKey(voteBucket, proposal), voter...)
+}
+
+func paramChangeKey(activation int, proposal []byte) []byte {
+	key := binary.BigEndian.AppendUint64(stateKey(paramChangeBucket, nil), uint64(activation))
+	return append(key, proposal...)
+}
+
+// GetProposal returns a proposal by its ID, the ID of the transaction that
+// made it
+func (bc *Blockchain) GetProposal(id []byte) (*Proposal, error) {
+	data, err := bc.db.Get(stateKey(proposalBucket, id), nil)
+	if err != nil {
+		return nil, fmt.Errorf("proposal %x does not exist", id)
+	}
+
+	return DeserializeProposal(data)
+}
+
+// Proposals returns all proposals
+func (bc *Blockchain) Proposals() ([]*Proposal, error) {
+	var proposals []*Proposal
+
+	iter := bc.db.NewIterator(util.BytesPrefix(stateKey(proposalBucket, nil)), nil)
+	defer iter.Release()
+	for iter.Next() {
+		proposal, err := DeserializeProposal(iter.Value())
+		if err != nil {
+			return nil, err
+		}
+		proposals = append(proposals, proposal)
+	}
+
+	return proposals, iter.Error()
+}
+
+// CheckGovernance checks the governance action of a transaction to be
+// mined in the block at height
+func (bc *Blockchain) CheckGovernance(tx *Transaction, height int) error {
+	for i, vin := range tx.Vin {
+		if vin.Governance != nil && (i > 0 || tx.IsCoinbase()) {
+			return fmt.Errorf("transaction %x carries a governance action on input %d", tx.ID, i)
+		}
+	}
+	if tx.IsCoinbase() || tx.Vin[0].Governance == nil {
+		return nil
+	}
+
+	action := tx.Vin[0].Governance
+	if tx.Vin[0].Contract != nil {
+		return fmt.Errorf("transaction %x carries both a contract call and a governance action", tx.ID)
+	}
+	if len(tx.Vin[0].RedeemScript) > 0 {
+		return fmt.Errorf("transaction %x: governance actions have to be signed with a key", tx.ID)
+	}
+	if err := action.check(); err != nil {
+		return fmt.Errorf("transaction %x: %s", tx.ID, err)
+	}
+
+	if len(action.Proposal) == 0 {
+		if err := checkParamChange(chainParams(), action.Param, action.Value); err != nil {
+			return fmt.Errorf("transaction %x: %s", tx.ID, err)
+		}
+		if earliest := height + governanceVotingPeriod + governanceActivationDelay; action.Activation < earliest {
+			return fmt.Errorf("transaction %x: the change can activate at height %d at the earliest", tx.ID, earliest)
+		}
+		return nil
+	}
+
+	proposal, err := bc.GetProposal(action.Proposal)
+	if err != nil {
+		return fmt.Errorf("transaction %x: %s", tx.ID, err)
+	}
+	if proposal.Status != ProposalVoting || height > proposal.End {
+		return fmt.Errorf("transaction %x: voting on proposal %x ended at height %d", tx.ID, proposal.ID, proposal.End)
+	}
+
+	return nil
+}
+
+// coinBalances sums the coins of the UTXO set by the hash they are locked
+// to, along with the total
+func (bc *Blockchain) coinBalances() (map[string]int, int) {
+	balances := make(map[string]int)
+	supply := 0
+
+	iter := bc.db.NewIterator(util.BytesPrefix([]byte(utxoBucket+"_")), nil)
+	for iter.Next() {
+		outs := DeserializeOutputs(iter.Value())
+		for _, out := range outs.Outputs {
+			if out.IsData() {
+				continue
+			}
+			balances[hex.EncodeToString(out.PubKeyHash)] += out.Value
+			supply += out.Value
+		}
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		log.Panic(err)
+	}
+
+	return balances, supply
+}
+
+// tallyProposals decides the proposals whose voting period ended before
+// the block at height. Votes weigh with the balance of the voter at the end
+// of the period, the last vote of each address counts.
+func (bc *Blockchain) tallyProposals(state *chainState, height int) {
+	proposals, err := bc.Proposals()
+	if err != nil {
+		log.Panic(err)
+	}
+
+	var balances map[string]int
+	supply := 0
+	for _, proposal := range proposals {
+		if proposal.Status != ProposalVoting || proposal.End >= height {
+			continue
+		}
+		if balances == nil {
+			balances, supply = bc.coinBalances()
+		}
+
+		prefix := stateKey(voteBucket, proposal.ID)
+		iter := bc.db.NewIterator(util.BytesPrefix(prefix), nil)
+		for iter.Next() {
+			weight := balances[hex.EncodeToString(iter.Key()[len(prefix):])]
+			if iter.Value()[0] == 1 {
+				proposal.Yes += weight
+			} else {
+				proposal.No += weight
+			}
+		}
+		iter.Release()
+		if err := iter.Error(); err != nil {
+			log.Panic(err)
+		}
+
+		voted := proposal.Yes + proposal.No
+		proposal.Supply = supply
+		proposal.Status = ProposalRejected
+		if voted > 0 && voted*100 >= governanceQuorum*supply && proposal.Yes*100 >= governanceThreshold*voted {
+			proposal.Status = ProposalPassed
+			state.put(paramChangeKey(proposal.Activation, proposal.ID), proposal.ID)
+		}
+		state.put(stateKey(proposalBucket, proposal.ID), proposal.Serialize())
+	}
+}
+
+// executeGovernance tallies the proposals whose voting ended, records the
+// proposals and votes of a block and adds the changes with the undo record
+// of the block to batch
+func (bc *Blockchain) executeGovernance(batch *leveldb.Batch, block *Block) {
+	state := newChainState(bc.db)
+	bc.tallyProposals(state, block.Height)
+
+	for _, tx := range block.Transactions {
+		if tx.IsCoinbase() || tx.Vin[0].Governance == nil {
+			continue
+		}
+		action := tx.Vin[0].Governance
+		actor := HashPubKey(tx.Vin[0].PubKey)
+
+		if len(action.Proposal) == 0 {
+			proposal := Proposal{
+				ID:         tx.ID,
+				Param:      action.Param,
+				Value:      action.Value,
+				Proposer:   actor,
+				Start:      block.Height,
+				End:        block.Height + governanceVotingPeriod - 1,
+				Activation: action.Activation,
+				Status:     ProposalVoting,
+			}
+			state.put(stateKey(proposalBucket, tx.ID), proposal.Serialize())
+			continue
+		}
+
+		vote := []byte{0}
+		if action.Approve {
+			vote[0] = 1
+		}
+		state.put(voteKey(action.Proposal, actor), vote)
+	}
+
+	state.write(batch, stateKey(governanceUndoBucket, block.Hash))
+}
+
+// disconnectGovernance adds restoring the governance state from before the
+// block to batch
+func (bc *Blockchain) disconnectGovernance(batch *leveldb.Batch, block *Block) {
+	bc.restoreState(batch, stateKey(governanceUndoBucket, block.Hash))
+}
+
+// ChainParamsAt returns the parameters in effect for the block at height,
+// the defaults with the passed changes activated up to it applied in order
+func (bc *Blockchain) ChainParamsAt(height int) ChainParams {
+	params := DefaultChainParams
+
+	prefix := stateKey(paramChangeBucket, nil)
+	iter := bc.db.NewIterator(util.BytesPrefix(prefix), nil)
+	defer iter.Release()
+	for iter.Next() {
+		if int(binary.BigEndian.Uint64(iter.Key()[len(prefix):])) > height {
+			break
+		}
+		proposal, err := bc.GetProposal(iter.Value())
+		if err != nil {
+			log.Panic(err)
+		}
+		params.set(proposal.Param, proposal.Value)
+	}
+	if err := iter.Error(); err != nil {
+		log.Panic(err)
+	}
+
+	return params
+}
+
+// activateParams makes the parameters in effect for the next block active
+func (bc *Blockchain) activateParams() {
+	activeChainParams = bc.ChainParamsAt(bc.GetBestHeight() + 1)
+}
+
+// NewGovernanceTransaction creates a transaction from a wallet address
+// carrying a proposal or a vote
+func NewGovernanceTransaction(from string, action GovernanceAction, selection CoinSelection, UTXOSet *UTXOSet) *Transaction {
+	if err := action.check(); err != nil {
+		log.Panic(err)
+	}
+	wallets, err := NewWallets()
+	if err != nil {
+		log.Panic(err)
+	}
+	wallet := wallets.GetWallet(from)
+	pubKeyHash := AddressToPubKeyHash(from)
+
+	inputs, prevOutputs, value := fundFee(nil, nil, 0, 1, pubKeyHash, selection, UTXOSet)
+	inputs[0].Governance = &action
+
+	var outputs []TXOutput
+	if change := value - selection.FeeRate.Fee(len(inputs), 1); change > 0 {
+		outputs = append(outputs, *NewTXOutput(change, from))
+	}
+
+	tx := Transaction{nil, inputs, outputs, 0}
+	tx.ID = tx.Hash()
+	tx.SignPrevOutputs(wallet.PrivateKey, prevOutputs, SigHashAll)
+
+	return &tx
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/tyler-smith/go-bip39"
+)
+
+// hdMasterKey is the HMAC key deriving the master key from a seed, keys are
+// derived as SLIP-0010 specifies for the NIST P-256 curve
+var hdMasterKey = []byte("Nist256p1 seed")
+
+// hdHardened marks the indexes of hardened child keys
+const hdHardened = 0x80000000
+
+// HD keys are derived at m/44'/0'/0'/chain/index, coins are received on
+// the receive chain and change goes to the change chain
+const (
+	HDReceiveChain = 0
+	HDChangeChain  = 1
+)
+
+// hdMnemonicBits is the entropy of new mnemonics, 24 words
+const hdMnemonicBits = 256
+
+// hdGapLimit is the number of unused addresses in a row after which a
+// restore stops looking for more on a chain
+const hdGapLimit = 20
+
+// HDChain is the seed of the deterministic keys of a wallet file, kept as
+// its mnemonic, and the index of the next key of the receive and the change
+// chain. The mnemonic of an encrypted wallet file is only kept as
+// EncryptedMnemonic, Mnemonic is empty while the wallet is locked.
+type HDChain struct {
+	Mnemonic          string
+	EncryptedMnemonic []byte
+	Next              [2]uint32
+}
+
+// extendedKey is a private key and its chain code
+type extendedKey struct {
+	key       *big.Int
+	chainCode []byte
+}
+
+// newMasterKey derives the master key of a seed
+func newMasterKey(seed []byte) extendedKey {
+	n := elliptic.P256().Params().N
+	data := seed
+
+	for {
+		mac := hmac.New(sha512.New, hdMasterKey)
+		mac.Write(data)
+		sum := mac.Sum(nil)
+
+		key := new(big.Int).SetBytes(sum[:32])
+		if key.Sign() > 0 && key.Cmp(n) < 0 {
+			return extendedKey{key, sum[32:]}
+		}
+		data = sum
+	}
+}
+
+// child derives the child key at index, indexes from hdHardened on are
+// derived from the private key, below from the public key
+func (k extendedKey) child(index uint32) extendedKey {
+	curve := elliptic.P256()
+	n := curve.Params().N
+
+	var data []byte
+	if index >= hdHardened {
+		data = append([]byte{0}, k.key.FillBytes(make([]byte, coordinateLen))...)
+	} else {
+		x, y := curve.ScalarBaseMult(k.key.FillBytes(make([]byte, coordinateLen)))
+		data = elliptic.MarshalCompressed(curve, x, y)
+	}
+
+	for {
+		mac := hmac.New(sha512.New, k.chainCode)
+		mac.Write(binary.BigEndian.AppendUint32(data, index))
+		sum := mac.Sum(nil)
+
+		tweak := new(big.Int).SetBytes(sum[:32])
+		key := new(big.Int).Add(tweak, k.key)
+		key.Mod(key, n)
+		if tweak.Cmp(n) < 0 && key.Sign() > 0 {
+			return extendedKey{key, sum[32:]}
+		}
+		data = append([]byte{1}, sum[32:]...)
+	}
+}
+
+// privateKey returns the ECDSA key of an extended key
+func (k extendedKey) privateKey() *ecdsa.PrivateKey {
+	curve := elliptic.P256()
+	private := &ecdsa.PrivateKey{D: k.key}
+	private.PublicKey.Curve = curve
+	private.PublicKey.X, private.PublicKey.Y = curve.ScalarBaseMult(k.key.FillBytes(make([]byte, coordinateLen)))
+
+	return private
+}
+
+// hdPath returns the derivation path of a key of a chain
+func hdPath(chain, index uint32) []uint32 {
+	return []uint32{44 + hdHardened, 0 + hdHardened, 0 + hdHardened, chain, index}
+}
+
+// FormatHDPath formats a derivation path the way BIP32 writes it
+func FormatHDPath(path []uint32) string {
+	var formatted strings.Builder
+	formatted.WriteString("m")
+	for _, index := range path {
+		if index >= hdHardened {
+			fmt.Fprintf(&formatted, "/%d'", index-hdHardened)
+		} else {
+			fmt.Fprintf(&formatted, "/%d", index)
+		}
+	}
+
+	return formatted.String()
+}
+
+// deriveHDWallet derives the wallet of a key of a chain from a seed
+func deriveHDWallet(seed []byte, chain, index uint32) *Wallet {
+	path := hdPath(chain, index)
+	key := newMasterKey(seed)
+	for _, i := range path {
+		key = key.child(i)
+	}
+	private := key.privateKey()
+
+	return &Wallet{*private, encodePubKey(&private.PublicKey), nil, FormatHDPath(path)}
+}
+
+// IsHD checks whether the wallet file has a seed for deterministic keys
+func (ws Wallets) IsHD() bool {
+	return ws.HD != nil
+}
+
+// CreateHDSeed gives the wallet file a seed from a new mnemonic and
+// returns the mnemonic
+func (ws *Wallets) CreateHDSeed() (string, error) {
+	if ws.HD != nil {
+		return "", errors.New("wallet already has an HD seed")
+	}
+
+	entropy, err := bip39.NewEntropy(hdMnemonicBits)
+	if err != nil {
+		return "", err
+	}
+	mnemonic, err := bip39.NewMnemonic(entropy)
+	if err != nil {
+		return "", err
+	}
+
+	ws.HD = &HDChain{Mnemonic: mnemonic}
+	return mnemonic, nil
+}
+
+// RestoreHDSeed gives the wallet file the seed of a mnemonic
+func (ws *Wallets) RestoreHDSeed(mnemonic string) error {
+	if ws.HD != nil {
+		return errors.New("wallet already has an HD seed")
+	}
+	mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return errors.New("mnemonic is not valid")
+	}
+
+	ws.HD = &HDChain{Mnemonic: mnemonic}
+	return nil
+}
+
+// hdSeed returns the seed of the mnemonic
+func (ws Wallets) hdSeed() ([]byte, error) {
+	if ws.HD == nil {
+		return nil, errors.New("wallet has no HD seed, create one with createwallet -hd")
+	}
+	if ws.HD.Mnemonic == "" {
+		return nil, ErrWalletLocked
+	}
+
+	return bip39.NewSeedWithErrorChecking(ws.HD.Mnemonic, "")
+}
+
+// NewHDAddress derives the next key of a chain, adds it to the wallets and
+// returns its address
+func (ws *Wallets) NewHDAddress(chain uint32) (string, error) {
+	seed, err := ws.hdSeed()
+	if err != nil {
+		return "", err
+	}
+
+	wallet := deriveHDWallet(seed, chain, ws.HD.Next[chain])
+	address := fmt.Sprintf("%s", wallet.GetAddress())
+	ws.Wallets[address] = wallet
+	ws.HD.Next[chain]++
+
+	return address, nil
+}
+
+// DiscoverHDAddresses adds the keys of both chains up to the last one used
+// whose address is in used, looking gapLimit addresses past the last used
+// one, and returns their number. The receive chain gets at least one key.
+func (ws *Wallets) DiscoverHDAddresses(used map[string]bool, gapLimit int) (int, error) {
+	seed, err := ws.hdSeed()
+	if err != nil {
+		return 0, err
+	}
+
+	found := 0
+	for _, chain := range []uint32{HDReceiveChain, HDChangeChain} {
+		for index, unused := ws.HD.Next[chain], 0; unused < gapLimit; index++ {
+			wallet := deriveHDWallet(seed, chain, index)
+			if !used[hex.EncodeToString(HashPubKey(wallet.PublicKey))] {
+				unused++
+				continue
+			}
+			unused = 0
+
+			for ; ws.HD.Next[chain] <= index; ws.HD.Next[chain]++ {
+				wallet := deriveHDWallet(seed, chain, ws.HD.Next[chain])
+				ws.Wallets[fmt.Sprintf("%s", wallet.GetAddress())] = wallet
+				found++
+			}
+		}
+	}
+
+	if ws.HD.Next[HDReceiveChain] == 0 {
+		if _, err := ws.NewHDAddress(HDReceiveChain); err != nil {
+			return found, err
+		}
+		found++
+	}
+
+	return found, nil
+}
+
+// PaidPubKeyHashes returns the hashes any output of the chain was ever
+// locked to, hex encoded
+func (bc *Blockchain) PaidPubKeyHashes() map[string]bool {
+	paid := make(map[string]bool)
+	bci := bc.Iterator()
+
+	for {
+		block := bci.Next()
+
+		for _, tx := range block.Transactions {
+			for _, out := range tx.Vout {
+				if !out.IsData() {
+					paid[hex.EncodeToString(out.PubKeyHash)] = true
+				}
+			}
+		}
+
+		if len(block.PrevBlockHash) == 0 {
+			break
+		}
+	}
+
+	return paid
+}
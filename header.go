@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// merkleBlockVersion is the first block version committing to a Merkle root
+// of its transactions and carrying a proposer signature. Blocks mined
+// before it hash the concatenated transaction IDs and are unsigned.
+const merkleBlockVersion = 1
+
+// BlockHeader is a block without its transactions, which MerkleRoot
+// commits to. Light clients keep only the chain of headers.
+type BlockHeader struct {
+	Version       int
+	Timestamp     int64
+	PrevBlockHash []byte
+	MerkleRoot    []byte
+	Hash          []byte
+	Stake         int64
+	Height        int
+	Proposer      []byte
+	Signature     []byte
+}
+
+// Header returns the header of the block
+func (b *Block) Header() BlockHeader {
+	return BlockHeader{
+		Version:       b.Version,
+		Timestamp:     b.Timestamp,
+		PrevBlockHash: b.PrevBlockHash,
+		MerkleRoot:    b.HashTransactions(),
+		Hash:          b.Hash,
+		Stake:         b.Stake,
+		Height:        b.Height,
+		Proposer:      b.Proposer,
+		Signature:     b.Signature,
+	}
+}
+
+// hashData returns the data the block hash is computed over. Versioned
+// blocks also commit to their height, stake and proposer.
+func (h BlockHeader) hashData() []byte {
+	parts := [][]byte{
+		h.PrevBlockHash,
+		h.MerkleRoot,
+		IntToHex(h.Timestamp),
+	}
+	if h.Version >= merkleBlockVersion {
+		parts = append(parts, IntToHex(int64(h.Version)), IntToHex(int64(h.Height)), IntToHex(h.Stake), h.Proposer)
+	}
+
+	return bytes.Join(parts, []byte{})
+}
+
+// Validate checks the hash, proposer signature and stake of the header and
+// that it extends prev, which is nil for the genesis header. Governance can
+// only raise the stake threshold, so the default one is the least stake
+// any header needs.
+func (h BlockHeader) Validate(prev *BlockHeader) error {
+	hash := sha256.Sum256(h.hashData())
+	if !bytes.Equal(hash[:], h.Hash) {
+		return fmt.Errorf("header %x does not match its hash", h.Hash)
+	}
+
+	if prev == nil {
+		if len(h.PrevBlockHash) != 0 || h.Height != 0 {
+			return fmt.Errorf("header %x is not a genesis header", h.Hash)
+		}
+	} else {
+		if !bytes.Equal(h.PrevBlockHash, prev.Hash) || h.Height != prev.Height+1 {
+			return fmt.Errorf("header %x does not extend %x", h.Hash, prev.Hash)
+		}
+		if h.Version < prev.Version {
+			return fmt.Errorf("header %x downgrades the block version", h.Hash)
+		}
+	}
+
+	if h.Version >= merkleBlockVersion && !verifySignature(h.Proposer, h.Hash, h.Signature) {
+		return fmt.Errorf("header %x has an invalid proposer signature", h.Hash)
+	}
+	if threshold := int64(DefaultChainParams.StakeThreshold); h.Stake <= threshold {
+		return fmt.Errorf("header %x stakes %d, more than %d is required", h.Hash, h.Stake, threshold)
+	}
+
+	return nil
+}
+
+// Serialize serializes the header
+func (h BlockHeader) Serialize() []byte {
+	var result bytes.Buffer
+	encoder := gob.NewEncoder(&result)
+
+	err := encoder.Encode(h)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return result.Bytes()
+}
+
+// DeserializeHeader deserializes a header
+func DeserializeHeader(d []byte) (*BlockHeader, error) {
+	var header BlockHeader
+
+	decoder := gob.NewDecoder(bytes.NewReader(d))
+	if err := decoder.Decode(&header); err != nil {
+		return nil, errors.New("invalid block header")
+	}
+
+	return &header, nil
+}
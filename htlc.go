@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+)
+
+// DecodeContract parses a hex encoded HTLC redeem script
+func DecodeContract(contractHex string) (*RedeemScript, error) {
+	data, err := hex.DecodeString(contractHex)
+	if err != nil {
+		return nil, err
+	}
+
+	script, err := DeserializeRedeemScript(data)
+	if err != nil {
+		return nil, err
+	}
+	if script.Type != ScriptHTLC {
+		return nil, errors.New("redeem script is not an HTLC contract")
+	}
+
+	return script, nil
+}
+
+// NewHTLCSpend creates a transaction that moves every output locked to the
+// contract. With a secret it pays the recipient, without one it is the
+// refund, which carries the contract lock time so it cannot be mined early.
+func NewHTLCSpend(script *RedeemScript, secret []byte, wallet *Wallet, UTXOSet *UTXOSet) *Transaction {
+	var inputs []TXInput
+
+	acc, validOutputs := UTXOSet.FindSpendableOutputs(script.Hash(), math.MaxInt)
+	if acc == 0 {
+		log.Panic("ERROR: Contract is not funded or already spent")
+	}
+
+	payTo := script.RefundHash
+	lockTime := script.LockTime
+	witnessLen := 2
+	if secret != nil {
+		payTo = script.RecipientHash
+		lockTime = 0
+		witnessLen = 3
+	}
+
+	redeemScript := script.Serialize()
+	for txid, outs := range validOutputs {
+		txID, err := hex.DecodeString(txid)
+		if err != nil {
+			log.Panic(err)
+		}
+
+		for _, out := range outs {
+			witness := make([][]byte, witnessLen)
+			if secret != nil {
+				witness[2] = secret
+			}
+			input := TXInput{Txid: txID, Vout: out, RedeemScript: redeemScript, Witness: witness}
+			inputs = append(inputs, input)
+		}
+	}
+
+	output := NewTXOutput(acc, fmt.Sprintf("%s", PubKeyHashToAddress(payTo)))
+
+	tx := Transaction{nil, inputs, []TXOutput{*output}, lockTime}
+	tx.ID = tx.Hash()
+	UTXOSet.Blockchain.SignTransaction(&tx, wallet.PrivateKey)
+
+	return &tx
+}
+
+// FindSwapSecret looks for a transaction redeeming the contract, in the
+// chain or the mempool, and returns the secret it revealed
+func (bc *Blockchain) FindSwapSecret(script *RedeemScript) ([]byte, error) {
+	redeemScript := script.Serialize()
+
+	findIn := func(tx *Transaction) []byte {
+		for _, vin := range tx.Vin {
+			if bytes.Equal(vin.RedeemScript, redeemScript) && len(vin.Witness) == 3 {
+				return vin.Witness[2]
+			}
+		}
+		return nil
+	}
+
+	for _, tx := range (Mempool{bc}).Transactions() {
+		if secret := findIn(tx); secret != nil {
+			return secret, nil
+		}
+	}
+
+	bci := bc.Iterator()
+	for {
+		block := bci.Next()
+
+		for _, tx := range block.Transactions {
+			if secret := findIn(tx); secret != nil {
+				return secret, nil
+			}
+		}
+
+		if len(block.PrevBlockHash) == 0 {
+			break
+		}
+	}
+
+	return nil, errors.New("contract has not been redeemed")
+}
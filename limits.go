@@ -0,0 +1,86 @@
+package main
+
+import "fmt"
+
+// blockReserve is the room block templates leave for the block header and
+// the coinbase transaction
+const blockReserve = 1000
+
+// SigOps returns how many signatures verifying the script may check
+func (rs RedeemScript) SigOps() int {
+	if rs.Type == ScriptMultiSig {
+		return len(rs.PubKeys)
+	}
+
+	return 1
+}
+
+// SigOps returns how many signature checks verifying the transaction takes,
+// one per key input and one per key of a script input
+func (tx Transaction) SigOps() int {
+	if tx.IsCoinbase() {
+		return 0
+	}
+
+	sigOps := 0
+	for _, vin := range tx.Vin {
+		if len(vin.RedeemScript) == 0 {
+			sigOps++
+			continue
+		}
+
+		// Scripts that do not decode fail verification anyway
+		script, err := DeserializeRedeemScript(vin.RedeemScript)
+		if err != nil {
+			sigOps++
+			continue
+		}
+		sigOps += script.SigOps()
+	}
+
+	return sigOps
+}
+
+// CheckLimits checks the transaction against the size, input, output and
+// signature check limits of the chain parameters
+func (tx *Transaction) CheckLimits() error {
+	params := chainParams()
+
+	if size := tx.Size(); size > params.MaxTxSize {
+		return fmt.Errorf("transaction %x has %d bytes, at most %d are allowed", tx.ID, size, params.MaxTxSize)
+	}
+	if len(tx.Vin) > params.MaxTxInputs {
+		return fmt.Errorf("transaction %x has %d inputs, at most %d are allowed", tx.ID, len(tx.Vin), params.MaxTxInputs)
+	}
+	if len(tx.Vout) > params.MaxTxOutputs {
+		return fmt.Errorf("transaction %x has %d outputs, at most %d are allowed", tx.ID, len(tx.Vout), params.MaxTxOutputs)
+	}
+	if sigOps := tx.SigOps(); sigOps > params.MaxBlockSigOps {
+		return fmt.Errorf("transaction %x takes %d signature checks, at most %d are allowed", tx.ID, sigOps, params.MaxBlockSigOps)
+	}
+
+	return nil
+}
+
+// CheckLimits checks the serialized size of the block, the limits of each
+// of its transactions and the signature checks of all of them together
+func (b *Block) CheckLimits() error {
+	params := chainParams()
+
+	if size := len(b.Serialize()); size > params.MaxBlockSize {
+		return fmt.Errorf("block has %d bytes, at most %d are allowed", size, params.MaxBlockSize)
+	}
+
+	sigOps := 0
+	for _, tx := range b.Transactions {
+		if err := tx.CheckLimits(); err != nil {
+			return err
+		}
+		sigOps += tx.SigOps()
+	}
+	if sigOps > params.MaxBlockSigOps {
+		return fmt.Errorf("block takes %d signature checks, at most %d are allowed", sigOps, params.MaxBlockSigOps)
+	}
+
+	return nil
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+const mempoolBucket = "mempool"
+
+// Mempool keeps accepted transactions until they are mined
+type Mempool struct {
+	Blockchain *Blockchain
+}
+
+// getMempoolKey returns the key with the mempool prefix
+func getMempoolKey(txID string) []byte {
+	return []byte(mempoolBucket + "_" + txID)
+}
+
+// outpointKey identifies an output spent by an input
+func outpointKey(txID []byte, outIdx int) string {
+	return fmt.Sprintf("%x:%d", txID, outIdx)
+}
+
+// AcceptTransaction validates a transaction against the chain tip and the
+// other pending transactions and adds it to the pool
+func (m Mempool) AcceptTransaction(tx *Transaction) error {
+	bc := m.Blockchain
+
+	if tx.IsCoinbase() {
+		return errors.New("coinbase transactions are only valid in blocks")
+	}
+	if err := tx.CheckID(); err != nil {
+		return err
+	}
+	if m.Has(tx.ID) {
+		return fmt.Errorf("transaction %x is already in the mempool", tx.ID)
+	}
+	if err := tx.CheckOutputs(); err != nil {
+		return err
+	}
+	if err := tx.CheckLimits(); err != nil {
+		return err
+	}
+
+	// Inputs may spend outputs of other pending transactions
+	pool := m.pool()
+	if err := bc.CheckInputs(tx, pool); err != nil {
+		return err
+	}
+	if err := bc.CheckAssets(tx, pool); err != nil {
+		return err
+	}
+	if err := bc.CheckNFTs(tx, pool); err != nil {
+		return err
+	}
+	if err := bc.CheckContract(tx, pool); err != nil {
+		return err
+	}
+
+	// Pending transactions spending the same outputs may only be replaced
+	spent := m.SpentOutputs()
+	var conflicts []*Transaction
+	seen := make(map[string]bool)
+	for _, vin := range tx.Vin {
+		spender, ok := spent[outpointKey(vin.Txid, vin.Vout)]
+		if !ok || seen[spender] {
+			continue
+		}
+		seen[spender] = true
+
+		spenderID, _ := hex.DecodeString(spender)
+		conflict, err := m.Get(spenderID)
+		if err != nil {
+			return err
+		}
+		conflicts = append(conflicts, conflict)
+	}
+
+	if !bc.verifyTransaction(tx, pool, nil) {
+		return fmt.Errorf("transaction %x has invalid signatures", tx.ID)
+	}
+
+	height := bc.GetBestHeight() + 1
+	medianTime := bc.MedianTimePast(bc.tip)
+	if err := bc.CheckTimeLocks(tx, height, medianTime, pool); err != nil {
+		return err
+	}
+	if err := bc.CheckCoinbaseMaturity(tx, height, pool); err != nil {
+		return err
+	}
+	if err := bc.CheckGovernance(tx, height); err != nil {
+		return err
+	}
+	if err := bc.CheckName(tx, height, pool); err != nil {
+		return err
+	}
+
+	// Replacing a transaction also evicts everything spending from it
+	batch := new(leveldb.Batch)
+	if len(conflicts) > 0 {
+		entries := m.entries()
+		evicted := txPackage{}
+		for _, conflict := range conflicts {
+			for id := range descendants(hex.EncodeToString(conflict.ID), entries) {
+				evicted[id] = true
+			}
+		}
+		for _, vin := range tx.Vin {
+			if evicted[hex.EncodeToString(vin.Txid)] {
+				return fmt.Errorf("transaction %x spends an output of a transaction it replaces", tx.ID)
+			}
+		}
+
+		if err := m.checkReplacement(tx, m.fee(tx, pool), conflicts, evicted, entries); err != nil {
+			return err
+		}
+		for id := range evicted {
+			batch.Delete(getMempoolKey(id))
+		}
+	}
+	batch.Put(getMempoolKey(hex.EncodeToString(tx.ID)), tx.Serialize())
+
+	if err := bc.db.Write(batch, nil); err != nil {
+		log.Panic(err)
+	}
+
+	if m.trim()[hex.EncodeToString(tx.ID)] {
+		return fmt.Errorf("mempool is full, transaction %x pays too little to enter it", tx.ID)
+	}
+
+	return nil
+}
+
+// Get returns a pending transaction
+func (m Mempool) Get(txID []byte) (*Transaction, error) {
+	data, err := m.Blockchain.db.Get(getMempoolKey(hex.EncodeToString(txID)), nil)
+	if err == leveldb.ErrNotFound {
+		return nil, fmt.Errorf("transaction %x is not in the mempool", txID)
+	}
+	if err != nil {
+		log.Panic(err)
+	}
+	tx := DeserializeTransaction(data)
+
+	return &tx, nil
+}
+
+// Has checks whether a transaction is pending
+func (m Mempool) Has(txID []byte) bool {
+	ok, err := m.Blockchain.db.Has(getMempoolKey(hex.EncodeToString(txID)), nil)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return ok
+}
+
+// Transactions returns all pending transactions
+func (m Mempool) Transactions() []*Transaction {
+	var txs []*Transaction
+	db := m.Blockchain.db
+
+	iter := db.NewIterator(nil, nil)
+	for iter.Next() {
+		key := iter.Key()
+		// Check if the key has the mempool prefix
+		if len(key) > len(mempoolBucket) && string(key[:len(mempoolBucket)]) == mempoolBucket {
+			tx := DeserializeTransaction(iter.Value())
+			txs = append(txs, &tx)
+		}
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		log.Panic(err)
+	}
+
+	return txs
+}
+
+// pool returns all pending transactions by hex ID
+func (m Mempool) pool() map[string]*Transaction {
+	pool := make(map[string]*Transaction)
+	for _, tx := range m.Transactions() {
+		pool[hex.EncodeToString(tx.ID)] = tx
+	}
+
+	return pool
+}
+
+// remove drops pending transactions by hex ID
+func (m Mempool) remove(ids txPackage) {
+	batch := new(leveldb.Batch)
+	for id := range ids {
+		batch.Delete(getMempoolKey(id))
+	}
+
+	if err := m.Blockchain.db.Write(batch, nil); err != nil {
+		log.Panic(err)
+	}
+}
+
+// SpentOutputs maps every output spent by a pending transaction to the
+// spending transaction ID
+func (m Mempool) SpentOutputs() map[string]string {
+	spent := make(map[string]string)
+
+	for _, tx := range m.Transactions() {
+		for _, vin := range tx.Vin {
+			spent[outpointKey(vin.Txid, vin.Vout)] = hex.EncodeToString(tx.ID)
+		}
+	}
+
+	return spent
+}
+
+// RemoveBlockTransactions drops transactions mined in the block, the
+// pending transactions that conflict with them and everything spending from
+// those conflicts
+func (m Mempool) RemoveBlockTransactions(block *Block) {
+	spent := make(map[string]bool)
+	for _, tx := range block.Transactions {
+		for _, vin := range tx.Vin {
+			spent[outpointKey(vin.Txid, vin.Vout)] = true
+		}
+	}
+
+	pool := m.Transactions()
+	removed := txPackage{}
+	for _, tx := range pool {
+		remove := false
+		for _, mined := range block.Transactions {
+			if bytes.Equal(mined.ID, tx.ID) {
+				remove = true
+			}
+		}
+		for _, vin := range tx.Vin {
+			if spent[outpointKey(vin.Txid, vin.Vout)] {
+				remove = true
+			}
+		}
+
+		if remove {
+			removed[hex.EncodeToString(tx.ID)] = true
+		}
+	}
+
+	// Children of mined transactions stay, their parents are confirmed now
+	conflicting := txPackage{}
+	for id := range removed {
+		conflicting[id] = true
+	}
+	for _, mined := range block.Transactions {
+		delete(conflicting, hex.EncodeToString(mined.ID))
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, tx := range pool {
+			id := hex.EncodeToString(tx.ID)
+			if removed[id] {
+				continue
+			}
+			for _, vin := range tx.Vin {
+				if conflicting[hex.EncodeToString(vin.Txid)] {
+					removed[id] = true
+					conflicting[id] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	m.remove(removed)
+}
+
+// MineBlock mines the pending transactions of the block template into a new
+// block rewarding address and updates the UTXO set and the pool. The block
+// is signed with the wallet key behind address.
+func (m Mempool) MineBlock(address string, stake int64) *Block {
+	bc := m.Blockchain
+	UTXOSet := UTXOSet{bc}
+
+	wallets, err := NewWallets()
+	if err != nil {
+		log.Panic(err)
+	}
+	proposer, err := wallets.ProposerKey(address)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	params := chainParams()
+	template := m.BlockTemplate(params.MaxBlockSize-blockReserve, params.MaxBlockSigOps)
+	fees, err := bc.BlockFees(template)
+	if err != nil {
+		log.Panic(err)
+	}
+	cbTx := NewCoinbaseTX(address, "", fees)
+	txs := append([]*Transaction{cbTx}, template...)
+
+	newBlock := bc.MineBlock(txs, stake, proposer.PrivateKey)
+	UTXOSet.Update(newBlock)
+	m.RemoveBlockTransactions(newBlock)
+
+	return newBlock
+}
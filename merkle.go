@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+)
+
+// Leaves and inner nodes are hashed with different prefixes so an inner
+// node can never be passed off as a transaction
+const merkleLeafPrefix = byte(0x00)
+const merkleNodePrefix = byte(0x01)
+
+// Leaves of the tree are whole serialized transactions rather than their
+// IDs, which do not cover signatures, so a proof binds every field a light
+// client reads
+
+// MerkleProof proves that a serialized transaction is committed to by a
+// Merkle root. Hashes are the siblings on the path from the leaf to the root, the
+// bits of Index tell on which side each of them is.
+type MerkleProof struct {
+	Index  int
+	Hashes [][]byte
+}
+
+func merkleLeaf(tx []byte) []byte {
+	hash := sha256.Sum256(append([]byte{merkleLeafPrefix}, tx...))
+
+	return hash[:]
+}
+
+func merkleNode(left, right []byte) []byte {
+	data := append([]byte{merkleNodePrefix}, left...)
+	hash := sha256.Sum256(append(data, right...))
+
+	return hash[:]
+}
+
+// merkleLevels returns every level of the tree over the serialized
+// transactions, leaves first. A level with an odd number of nodes pairs the
+// last one with itself.
+func merkleLevels(txs [][]byte) [][][]byte {
+	var level [][]byte
+	for _, tx := range txs {
+		level = append(level, merkleLeaf(tx))
+	}
+	if len(level) == 0 {
+		level = append(level, merkleLeaf(nil))
+	}
+
+	levels := [][][]byte{level}
+	for len(level) > 1 {
+		var next [][]byte
+		for i := 0; i < len(level); i += 2 {
+			right := level[i]
+			if i+1 < len(level) {
+				right = level[i+1]
+			}
+			next = append(next, merkleNode(level[i], right))
+		}
+		level = next
+		levels = append(levels, level)
+	}
+
+	return levels
+}
+
+// MerkleRoot returns the root of the Merkle tree over the serialized
+// transactions
+func MerkleRoot(txs [][]byte) []byte {
+	levels := merkleLevels(txs)
+
+	return levels[len(levels)-1][0]
+}
+
+// NewMerkleProof returns the proof that the transaction at index is part
+// of the tree over txs
+func NewMerkleProof(txs [][]byte, index int) MerkleProof {
+	proof := MerkleProof{Index: index}
+
+	levels := merkleLevels(txs)
+	for _, level := range levels[:len(levels)-1] {
+		sibling := index ^ 1
+		if sibling >= len(level) {
+			sibling = index
+		}
+		proof.Hashes = append(proof.Hashes, level[sibling])
+		index /= 2
+	}
+
+	return proof
+}
+
+// Verify checks that the proof leads from the serialized transaction to root
+func (p MerkleProof) Verify(tx, root []byte) bool {
+	if p.Index < 0 || p.Index>>len(p.Hashes) != 0 {
+		return false
+	}
+
+	hash := merkleLeaf(tx)
+	index := p.Index
+	for _, sibling := range p.Hashes {
+		if index%2 == 0 {
+			hash = merkleNode(hash, sibling)
+		} else {
+			hash = merkleNode(sibling, hash)
+		}
+		index /= 2
+	}
+
+	return bytes.Equal(hash, root)
+}
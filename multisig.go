@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/hex"
+	"log"
+)
+
+// NewMultiSigTransaction creates a transaction spending from a multisig
+// address and signs it with every cosigner key found in the local wallet
+func NewMultiSigTransaction(from, to string, amount int, lockTime int64, sequence uint32, UTXOSet *UTXOSet) *Transaction {
+	var inputs []TXInput
+	var outputs []TXOutput
+
+	wallets, err := NewWallets()
+	if err != nil {
+		log.Panic(err)
+	}
+	script, err := wallets.GetScript(from)
+	if err != nil {
+		log.Panic(err)
+	}
+	redeemScript := script.Serialize()
+	acc, validOutputs := UTXOSet.FindSpendableOutputs(script.Hash(), amount)
+
+	if acc < amount {
+		log.Panic("ERROR: Not enough funds")
+	}
+
+	// Build a list of inputs with an empty signature slot per cosigner
+	for txid, outs := range validOutputs {
+		txID, err := hex.DecodeString(txid)
+		if err != nil {
+			log.Panic(err)
+		}
+
+		for _, out := range outs {
+			input := TXInput{
+				Txid:         txID,
+				Vout:         out,
+				Sequence:     sequence,
+				RedeemScript: redeemScript,
+				Witness:      make([][]byte, len(script.PubKeys)),
+			}
+			inputs = append(inputs, input)
+		}
+	}
+
+	// Build a list of outputs
+	outputs = append(outputs, *NewTXOutput(amount, to))
+	if acc > amount {
+		outputs = append(outputs, *NewTXOutput(acc-amount, from)) // a change
+	}
+
+	tx := Transaction{nil, inputs, outputs, lockTime}
+	tx.ID = tx.Hash()
+	UTXOSet.Blockchain.SignMultiSig(&tx, wallets)
+
+	return &tx
+}
+
+// SignMultiSig adds the signatures of all local cosigner keys to the script
+// inputs of a transaction and returns the number of keys that signed
+func (bc *Blockchain) SignMultiSig(tx *Transaction, wallets *Wallets) int {
+	signers := make(map[string]*Wallet)
+
+	for _, vin := range tx.Vin {
+		if len(vin.RedeemScript) == 0 {
+			continue
+		}
+		script, err := DeserializeRedeemScript(vin.RedeemScript)
+		if err != nil {
+			log.Panic(err)
+		}
+
+		for _, pubKey := range script.PubKeys {
+			if wallet := wallets.FindByPubKey(pubKey); wallet != nil {
+				signers[hex.EncodeToString(pubKey)] = wallet
+			}
+		}
+	}
+
+	for _, wallet := range signers {
+		bc.SignTransaction(tx, wallet.PrivateKey)
+	}
+
+	return len(signers)
+}
+
+// MissingSignatures returns how many more signatures the script inputs of
+// the transaction need before it can be mined
+func (tx Transaction) MissingSignatures() int {
+	missing := 0
+
+	for _, vin := range tx.Vin {
+		if len(vin.RedeemScript) == 0 {
+			continue
+		}
+		script, err := DeserializeRedeemScript(vin.RedeemScript)
+		if err != nil {
+			log.Panic(err)
+		}
+
+		if script.MissingSignatures(vin.Witness) > missing {
+			missing = script.MissingSignatures(vin.Witness)
+		}
+	}
+
+	return missing
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+)
+
+const maxMuSigKeys = maxMultiSigKeys
+const noncePairLen = 2 * compressedPubKeyLen
+
+// AggregateKeys combines compressed public keys into a single MuSig key
+// X = sum a_i*P_i, where the coefficients a_i bind every key to the whole
+// key set so no participant can cancel out the others. The key order does
+// not matter.
+func AggregateKeys(pubKeys [][]byte) ([]byte, error) {
+	keys, err := sortMuSigKeys(pubKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	var aggX, aggY *big.Int
+	for _, key := range keys {
+		x, y := elliptic.UnmarshalCompressed(schnorrCurve, key)
+		a := keyAggCoefficient(keys, key)
+		ax, ay := schnorrCurve.ScalarMult(x, y, a.Bytes())
+
+		if aggX == nil {
+			aggX, aggY = ax, ay
+		} else {
+			aggX, aggY = schnorrCurve.Add(aggX, aggY, ax, ay)
+		}
+	}
+	if isInfinity(aggX, aggY) {
+		return nil, errors.New("aggregated key is the point at infinity")
+	}
+
+	return elliptic.MarshalCompressed(schnorrCurve, aggX, aggY), nil
+}
+
+// sortMuSigKeys validates and sorts the participant keys
+func sortMuSigKeys(pubKeys [][]byte) ([][]byte, error) {
+	if len(pubKeys) == 0 || len(pubKeys) > maxMuSigKeys {
+		return nil, fmt.Errorf("key aggregation needs between 1 and %d public keys", maxMuSigKeys)
+	}
+
+	keys := append([][]byte{}, pubKeys...)
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+	for i, key := range keys {
+		if len(key) != compressedPubKeyLen {
+			return nil, fmt.Errorf("public key %x is not compressed", key)
+		}
+		if _, err := decodePubKey(key); err != nil {
+			return nil, err
+		}
+		if i > 0 && bytes.Equal(keys[i-1], key) {
+			return nil, fmt.Errorf("public key %x is listed twice", key)
+		}
+	}
+
+	return keys, nil
+}
+
+// keyAggCoefficient returns a_i = H(L || P_i) for the sorted key list L
+func keyAggCoefficient(keys [][]byte, pubKey []byte) *big.Int {
+	keyList := taggedHash("MuSig/keylist", keys...)
+
+	return hashToScalar("MuSig/coefficient", keyList, pubKey)
+}
+
+// MuSigSession collects the nonces and partial signatures of the
+// participants of an aggregated key for one input. Signing takes two
+// rounds: every participant publishes a nonce pair, then every participant
+// signs once all nonces are known.
+type MuSigSession struct {
+	Keys        [][]byte
+	Nonces      map[string][]byte
+	PartialSigs map[string][]byte
+}
+
+// NewMuSigSession starts a signing session for the participant keys
+func NewMuSigSession(keys [][]byte) (*MuSigSession, error) {
+	sorted, err := sortMuSigKeys(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &MuSigSession{
+		Keys:        sorted,
+		Nonces:      make(map[string][]byte),
+		PartialSigs: make(map[string][]byte),
+	}
+
+	return session, nil
+}
+
+// AddNonce generates a fresh nonce pair for a participant, publishes the
+// public part in the session and returns the secret part, which must be
+// kept until the participant signs and never used again
+func (s *MuSigSession) AddNonce(pubKey []byte) []byte {
+	curveOrder := schnorrCurve.Params().N
+	secret := make([]byte, 2*coordinateLen)
+	public := make([]byte, 0, noncePairLen)
+
+	for i := 0; i < 2; i++ {
+		k, err := rand.Int(rand.Reader, new(big.Int).Sub(curveOrder, big.NewInt(1)))
+		if err != nil {
+			log.Panic(err)
+		}
+		k.Add(k, big.NewInt(1))
+		k.FillBytes(secret[i*coordinateLen : (i+1)*coordinateLen])
+
+		x, y := schnorrCurve.ScalarBaseMult(k.Bytes())
+		public = append(public, elliptic.MarshalCompressed(schnorrCurve, x, y)...)
+	}
+
+	s.Nonces[hex.EncodeToString(pubKey)] = public
+	return secret
+}
+
+// HasAllNonces checks whether every participant published a nonce pair
+func (s MuSigSession) HasAllNonces() bool {
+	return len(s.Nonces) == len(s.Keys)
+}
+
+// HasAllPartialSigs checks whether every participant signed
+func (s MuSigSession) HasAllPartialSigs() bool {
+	return len(s.PartialSigs) == len(s.Keys)
+}
+
+// noncePoints returns the public nonce pair R1, R2 of a participant
+func (s MuSigSession) noncePoints(pubKey []byte) (*big.Int, *big.Int, *big.Int, *big.Int, error) {
+	nonce, ok := s.Nonces[hex.EncodeToString(pubKey)]
+	if !ok || len(nonce) != noncePairLen {
+		return nil, nil, nil, nil, fmt.Errorf("no nonce from %x", pubKey)
+	}
+
+	r1x, r1y := elliptic.UnmarshalCompressed(schnorrCurve, nonce[:compressedPubKeyLen])
+	r2x, r2y := elliptic.UnmarshalCompressed(schnorrCurve, nonce[compressedPubKeyLen:])
+	if r1x == nil || r2x == nil {
+		return nil, nil, nil, nil, fmt.Errorf("invalid nonce from %x", pubKey)
+	}
+
+	return r1x, r1y, r2x, r2y, nil
+}
+
+// signingNonce returns the x coordinate of the final nonce R = R1 + b*R2
+// summed over all participants, the nonce coefficient b and whether R was
+// negated to give it an even y coordinate
+func (s MuSigSession) signingNonce(aggKey, msg []byte) ([]byte, *big.Int, bool, error) {
+	var sum1x, sum1y, sum2x, sum2y *big.Int
+
+	for _, key := range s.Keys {
+		r1x, r1y, r2x, r2y, err := s.noncePoints(key)
+		if err != nil {
+			return nil, nil, false, err
+		}
+
+		if sum1x == nil {
+			sum1x, sum1y, sum2x, sum2y = r1x, r1y, r2x, r2y
+		} else {
+			sum1x, sum1y = schnorrCurve.Add(sum1x, sum1y, r1x, r1y)
+			sum2x, sum2y = schnorrCurve.Add(sum2x, sum2y, r2x, r2y)
+		}
+	}
+	if isInfinity(sum1x, sum1y) || isInfinity(sum2x, sum2y) {
+		return nil, nil, false, errors.New("aggregated nonce is the point at infinity")
+	}
+
+	b := hashToScalar("MuSig/noncecoef",
+		elliptic.MarshalCompressed(schnorrCurve, sum1x, sum1y),
+		elliptic.MarshalCompressed(schnorrCurve, sum2x, sum2y),
+		aggKey, msg)
+	bx, by := schnorrCurve.ScalarMult(sum2x, sum2y, b.Bytes())
+	rx, ry := schnorrCurve.Add(sum1x, sum1y, bx, by)
+	if isInfinity(rx, ry) {
+		return nil, nil, false, errors.New("signing nonce is the point at infinity")
+	}
+
+	encoded := make([]byte, coordinateLen)
+	rx.FillBytes(encoded)
+
+	return encoded, b, ry.Bit(0) == 1, nil
+}
+
+// PartialSign adds the partial signature s_i = g*(k1 + b*k2) + e*a_i*x_i of a
+// participant, g being -1 when the final nonce was negated
+func (s *MuSigSession) PartialSign(privKey ecdsa.PrivateKey, secretNonce, aggKey, msg []byte) error {
+	if privKey.D == nil {
+		return ErrWalletLocked
+	}
+	pubKey := encodePubKey(&privKey.PublicKey)
+	if len(secretNonce) != 2*coordinateLen {
+		return errors.New("invalid secret nonce")
+	}
+
+	rx, b, negated, err := s.signingNonce(aggKey, msg)
+	if err != nil {
+		return err
+	}
+
+	curveOrder := schnorrCurve.Params().N
+	k1 := new(big.Int).SetBytes(secretNonce[:coordinateLen])
+	k2 := new(big.Int).SetBytes(secretNonce[coordinateLen:])
+	k := new(big.Int).Mul(b, k2)
+	k.Add(k, k1)
+	if negated {
+		k.Neg(k)
+	}
+
+	e := schnorrChallenge(rx, aggKey, msg)
+	ex := new(big.Int).Mul(e, keyAggCoefficient(s.Keys, pubKey))
+	ex.Mul(ex, privKey.D)
+
+	partial := k.Add(k, ex)
+	partial.Mod(partial, curveOrder)
+
+	encoded := make([]byte, coordinateLen)
+	partial.FillBytes(encoded)
+	s.PartialSigs[hex.EncodeToString(pubKey)] = encoded
+
+	return nil
+}
+
+// verifyPartialSig checks s_i*G = g*(R1_i + b*R2_i) + e*a_i*P_i
+func (s MuSigSession) verifyPartialSig(pubKey, rx []byte, b *big.Int, negated bool, aggKey, msg []byte) bool {
+	partial, ok := s.PartialSigs[hex.EncodeToString(pubKey)]
+	if !ok || len(partial) != coordinateLen {
+		return false
+	}
+	r1x, r1y, r2x, r2y, err := s.noncePoints(pubKey)
+	if err != nil {
+		return false
+	}
+
+	bx, by := schnorrCurve.ScalarMult(r2x, r2y, b.Bytes())
+	nx, ny := schnorrCurve.Add(r1x, r1y, bx, by)
+	if negated {
+		nx, ny = negatePoint(nx, ny)
+	}
+
+	px, py := elliptic.UnmarshalCompressed(schnorrCurve, pubKey)
+	e := schnorrChallenge(rx, aggKey, msg)
+	ea := new(big.Int).Mul(e, keyAggCoefficient(s.Keys, pubKey))
+	ea.Mod(ea, schnorrCurve.Params().N)
+	ex, ey := schnorrCurve.ScalarMult(px, py, ea.Bytes())
+
+	expectedX, expectedY := schnorrCurve.Add(nx, ny, ex, ey)
+	sx, sy := schnorrCurve.ScalarBaseMult(partial)
+
+	return sx.Cmp(expectedX) == 0 && sy.Cmp(expectedY) == 0
+}
+
+// Aggregate checks every partial signature and sums them into a Schnorr
+// signature R.x || s valid under the aggregated key
+func (s MuSigSession) Aggregate(aggKey, msg []byte) ([]byte, error) {
+	if !s.HasAllPartialSigs() {
+		return nil, errors.New("partial signatures are missing")
+	}
+
+	rx, b, negated, err := s.signingNonce(aggKey, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := new(big.Int)
+	for _, key := range s.Keys {
+		if !s.verifyPartialSig(key, rx, b, negated, aggKey, msg) {
+			return nil, fmt.Errorf("invalid partial signature from %x", key)
+		}
+		sum.Add(sum, new(big.Int).SetBytes(s.PartialSigs[hex.EncodeToString(key)]))
+	}
+	sum.Mod(sum, schnorrCurve.Params().N)
+
+	signature := make([]byte, schnorrSigLen)
+	copy(signature, rx)
+	sum.FillBytes(signature[coordinateLen:])
+
+	return signature, nil
+}
+
+// Merge adds the nonces and partial signatures of another copy of the
+// session
+func (s *MuSigSession) Merge(other *MuSigSession) {
+	for key, nonce := range other.Nonces {
+		if _, ok := s.Nonces[key]; !ok {
+			s.Nonces[key] = nonce
+		}
+	}
+	for key, partial := range other.PartialSigs {
+		if _, ok := s.PartialSigs[key]; !ok {
+			s.PartialSigs[key] = partial
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func newTestKeys(t *testing.T, n int) ([]*ecdsa.PrivateKey, [][]byte) {
+	t.Helper()
+
+	var privKeys []*ecdsa.PrivateKey
+	var pubKeys [][]byte
+	for i := 0; i < n; i++ {
+		privKey, err := ecdsa.GenerateKey(schnorrCurve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privKeys = append(privKeys, privKey)
+		pubKeys = append(pubKeys, encodePubKey(&privKey.PublicKey))
+	}
+
+	return privKeys, pubKeys
+}
+
+// muSigSession runs both signing rounds for every key and returns the
+// session with all partial signatures
+func muSigSession(t *testing.T, privKeys []*ecdsa.PrivateKey, pubKeys [][]byte, aggKey, msg []byte) *MuSigSession {
+	t.Helper()
+
+	session, err := NewMuSigSession(pubKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets := make([][]byte, len(privKeys))
+	for i, pubKey := range pubKeys {
+		secrets[i] = session.AddNonce(pubKey)
+	}
+	if !session.HasAllNonces() {
+		t.Fatal("nonces are missing after the first round")
+	}
+	for i, privKey := range privKeys {
+		if err := session.PartialSign(*privKey, secrets[i], aggKey, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return session
+}
+
+func muSigSign(t *testing.T, privKeys []*ecdsa.PrivateKey, pubKeys [][]byte, msg []byte) ([]byte, []byte) {
+	t.Helper()
+
+	aggKey, err := AggregateKeys(pubKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := muSigSession(t, privKeys, pubKeys, aggKey, msg).Aggregate(aggKey, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return aggKey, signature
+}
+
+func TestMuSigRoundTrip(t *testing.T) {
+	for _, n := range []int{1, 2, 3, 5} {
+		privKeys, pubKeys := newTestKeys(t, n)
+		msg := sha256.Sum256([]byte("musig round trip"))
+
+		aggKey, signature := muSigSign(t, privKeys, pubKeys, msg[:])
+		if !verifySchnorr(aggKey, msg[:], signature) {
+			t.Errorf("%d of %d signature does not verify", n, n)
+		}
+
+		other := sha256.Sum256([]byte("another message"))
+		if verifySchnorr(aggKey, other[:], signature) {
+			t.Errorf("%d of %d signature verifies for another message", n, n)
+		}
+		_, otherKeys := newTestKeys(t, n)
+		otherAggKey, err := AggregateKeys(otherKeys)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if verifySchnorr(otherAggKey, msg[:], signature) {
+			t.Errorf("%d of %d signature verifies under another key", n, n)
+		}
+	}
+}
+
+func TestMuSigNonceNegation(t *testing.T) {
+	privKeys, pubKeys := newTestKeys(t, 3)
+	aggKey, err := AggregateKeys(pubKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Half of all nonces have an odd y coordinate and get negated, sign
+	// until both cases verified
+	seen := make(map[bool]bool)
+	for i := 0; i < 128 && len(seen) < 2; i++ {
+		msg := sha256.Sum256([]byte{byte(i)})
+		session := muSigSession(t, privKeys, pubKeys, aggKey, msg[:])
+
+		_, _, negated, err := session.signingNonce(aggKey, msg[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature, err := session.Aggregate(aggKey, msg[:])
+		if err != nil {
+			t.Fatalf("negated %v: %s", negated, err)
+		}
+		if !verifySchnorr(aggKey, msg[:], signature) {
+			t.Fatalf("signature with negated nonce %v does not verify", negated)
+		}
+		seen[negated] = true
+	}
+	if len(seen) < 2 {
+		t.Fatal("signing never produced both a negated and a plain nonce")
+	}
+}
+
+func TestMuSigRejectsTamperedPartialSig(t *testing.T) {
+	privKeys, pubKeys := newTestKeys(t, 3)
+	aggKey, err := AggregateKeys(pubKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := sha256.Sum256([]byte("tampered partial signature"))
+
+	session := muSigSession(t, privKeys, pubKeys, aggKey, msg[:])
+	partial := session.PartialSigs[hex.EncodeToString(pubKeys[1])]
+	partial[len(partial)-1] ^= 1
+
+	if _, err := session.Aggregate(aggKey, msg[:]); err == nil {
+		t.Fatal("aggregated a tampered partial signature")
+	}
+}
+
+func TestMuSigNeedsAllPartialSigs(t *testing.T) {
+	privKeys, pubKeys := newTestKeys(t, 2)
+	aggKey, err := AggregateKeys(pubKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := sha256.Sum256([]byte("missing partial signature"))
+
+	session := muSigSession(t, privKeys, pubKeys, aggKey, msg[:])
+	delete(session.PartialSigs, hex.EncodeToString(pubKeys[0]))
+
+	if _, err := session.Aggregate(aggKey, msg[:]); err == nil {
+		t.Fatal("aggregated without every partial signature")
+	}
+}
+
+func TestAggregateKeysOrderIndependent(t *testing.T) {
+	_, pubKeys := newTestKeys(t, 3)
+	aggKey, err := AggregateKeys(pubKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	orders := [][]int{{0, 2, 1}, {1, 0, 2}, {1, 2, 0}, {2, 0, 1}, {2, 1, 0}}
+	for _, order := range orders {
+		var permuted [][]byte
+		for _, i := range order {
+			permuted = append(permuted, pubKeys[i])
+		}
+		permutedKey, err := AggregateKeys(permuted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(aggKey, permutedKey) {
+			t.Errorf("order %v aggregates to %x, not %x", order, permutedKey, aggKey)
+		}
+	}
+
+	subsetKey, err := AggregateKeys(pubKeys[:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(aggKey, subsetKey) {
+		t.Error("a subset of the keys aggregates to the same key")
+	}
+}
+
+func TestAggregateKeysRejectsDuplicates(t *testing.T) {
+	_, pubKeys := newTestKeys(t, 2)
+
+	if _, err := AggregateKeys([][]byte{pubKeys[0], pubKeys[1], pubKeys[0]}); err == nil {
+		t.Fatal("aggregated a key listed twice")
+	}
+	if _, err := AggregateKeys(nil); err == nil {
+		t.Fatal("aggregated an empty key list")
+	}
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// Registered names and the undo records of each block live in the
+// blockchain database under their own prefixes
+const nameBucket = "name"
+const nameUndoBucket = "nameundo"
+
+const maxNameLen = 32
+
+// namePeriod is the number of blocks a claim or renewal registers a name
+// for, nameFee the fee either has to pay
+const namePeriod = 100
+const nameFee = 5
+
+// Name operations
+const (
+	NameClaim = iota
+	NameRenew
+	NameTransfer
+)
+
+// NameAction claims, renews or transfers the name Name. A claim registers
+// an unregistered or expired name to the address signing the first input,
+// which the action rides on. Renewals and transfers have to be signed by
+// the owner before the name expires, transfers hand it to the key hash To.
+type NameAction struct {
+	Op   int
+	Name string
+	To   []byte
+}
+
+// ValidateName checks that a name is 1 to maxNameLen lowercase letters,
+// digits and inner hyphens starting with a letter, which no address is
+func ValidateName(name string) error {
+	if len(name) == 0 || len(name) > maxNameLen {
+		return fmt.Errorf("a name has 1 to %d characters", maxNameLen)
+	}
+	for i, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z':
+		case c >= '0' && c <= '9' && i > 0:
+		case c == '-' && i > 0 && i < len(name)-1:
+		default:
+			return fmt.Errorf("name %q is not valid, use lowercase letters, digits and hyphens starting with a letter", name)
+		}
+	}
+
+	return nil
+}
+
+// check enforces the name rules that need no chain state
+func (na NameAction) check() error {
+	if err := ValidateName(na.Name); err != nil {
+		return err
+	}
+
+	switch na.Op {
+	case NameClaim, NameRenew:
+		if len(na.To) > 0 {
+			return errors.New("only transfers name a new owner")
+		}
+	case NameTransfer:
+		if len(na.To) != pubKeyHashLen {
+			return errors.New("new owner is not valid")
+		}
+	default:
+		return fmt.Errorf("unknown name operation %d", na.Op)
+	}
+
+	return nil
+}
+
+// Fee returns the fee the action has to pay
+func (na NameAction) Fee() int {
+	if na.Op == NameTransfer {
+		return 0
+	}
+
+	return nameFee
+}
+
+// NameRecord is a registered name, its owner and the last height it is
+// registered at
+type NameRecord struct {
+	Name    string
+	Owner   []byte
+	Expires int
+}
+
+// Serialize serializes the record
+func (r NameRecord) Serialize() []byte {
+	var encoded bytes.Buffer
+
+	enc := gob.NewEncoder(&encoded)
+	err := enc.Encode(r)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return encoded.Bytes()
+}
+
+// DeserializeNameRecord deserializes a name record
+func DeserializeNameRecord(data []byte) (*NameRecord, error) {
+	var record NameRecord
+
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&record); err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+func nameKey(name string) []byte {
+	return stateKey(nameBucket, []byte(name))
+}
+
+// GetName returns the record of a name, registered or expired
+func (bc *Blockchain) GetName(name string) (*NameRecord, error) {
+	data, err := bc.db.Get(nameKey(name), nil)
+	if err != nil {
+		return nil, fmt.Errorf("name %s is not registered", name)
+	}
+
+	return DeserializeNameRecord(data)
+}
+
+// ResolveName returns the address a name is registered to at height
+func (bc *Blockchain) ResolveName(name string, height int) (string, error) {
+	record, err := bc.GetName(name)
+	if err != nil {
+		return "", err
+	}
+	if record.Expires < height {
+		return "", fmt.Errorf("name %s expired at height %d", name, record.Expires)
+	}
+
+	return string(PubKeyHashToAddress(record.Owner)), nil
+}
+
+// applyName returns the record of the name after the action of a
+// transaction by actor in the block at height, record is nil for names
+// never registered
+func applyName(record *NameRecord, action *NameAction, actor []byte, height int) (*NameRecord, error) {
+	if action.Op == NameClaim {
+		if record != nil && record.Expires >= height {
+			return nil, fmt.Errorf("name %s is registered until height %d", action.Name, record.Expires)
+		}
+		return &NameRecord{action.Name, actor, height + namePeriod - 1}, nil
+	}
+
+	if record == nil || record.Expires < height {
+		return nil, fmt.Errorf("name %s is not registered", action.Name)
+	}
+	if !bytes.Equal(record.Owner, actor) {
+		return nil, fmt.Errorf("name %s is owned by %s", action.Name, PubKeyHashToAddress(record.Owner))
+	}
+
+	updated := *record
+	if action.Op == NameRenew {
+		updated.Expires += namePeriod
+	} else {
+		updated.Owner = action.To
+	}
+
+	return &updated, nil
+}
+
+// CheckName checks the name action of a transaction to be mined in the
+// block at height. Only one transaction among it and parents may act on a
+// name, parents spending the same outputs are replaced by it and ignored.
+func (bc *Blockchain) CheckName(tx *Transaction, height int, parents map[string]*Transaction) error {
+	for i, vin := range tx.Vin {
+		if vin.Name != nil && (i > 0 || tx.IsCoinbase()) {
+			return fmt.Errorf("transaction %x carries a name action on input %d", tx.ID, i)
+		}
+	}
+	if tx.IsCoinbase() || tx.Vin[0].Name == nil {
+		return nil
+	}
+
+	action := tx.Vin[0].Name
+	if tx.Vin[0].Contract != nil || tx.Vin[0].Governance != nil {
+		return fmt.Errorf("transaction %x carries a name action besides another action", tx.ID)
+	}
+	if len(tx.Vin[0].RedeemScript) > 0 {
+		return fmt.Errorf("transaction %x: name actions have to be signed with a key", tx.ID)
+	}
+	if err := action.check(); err != nil {
+		return fmt.Errorf("transaction %x: %s", tx.ID, err)
+	}
+
+	spends := make(map[string]bool)
+	for _, vin := range tx.Vin {
+		spends[outpointKey(vin.Txid, vin.Vout)] = true
+	}
+	for _, parent := range parents {
+		if parent.IsCoinbase() || parent.Vin[0].Name == nil || parent.Vin[0].Name.Name != action.Name {
+			continue
+		}
+		replaced := false
+		for _, vin := range parent.Vin {
+			replaced = replaced || spends[outpointKey(vin.Txid, vin.Vout)]
+		}
+		if !replaced {
+			return fmt.Errorf("transaction %x: transaction %x already acts on name %s", tx.ID, parent.ID, action.Name)
+		}
+	}
+
+	record, err := bc.GetName(action.Name)
+	if err != nil {
+		record = nil
+	}
+	if _, err := applyName(record, action, HashPubKey(tx.Vin[0].PubKey), height); err != nil {
+		return fmt.Errorf("transaction %x: %s", tx.ID, err)
+	}
+
+	fee, err := bc.TransactionFee(tx, parents)
+	if err != nil {
+		return err
+	}
+	if fee < action.Fee() {
+		return fmt.Errorf("transaction %x pays %d, the name costs %d", tx.ID, fee, action.Fee())
+	}
+
+	return nil
+}
+
+// executeNames applies the name actions of a block and adds the changed
+// records with the undo record of the block to batch
+func (bc *Blockchain) executeNames(batch *leveldb.Batch, block *Block) {
+	state := newChainState(bc.db)
+
+	for _, tx := range block.Transactions {
+		if tx.IsCoinbase() || tx.Vin[0].Name == nil {
+			continue
+		}
+		action := tx.Vin[0].Name
+
+		var record *NameRecord
+		if data := state.get(nameKey(action.Name)); data != nil {
+			var err error
+			if record, err = DeserializeNameRecord(data); err != nil {
+				log.Panic(err)
+			}
+		}
+		updated, err := applyName(record, action, HashPubKey(tx.Vin[0].PubKey), block.Height)
+		if err != nil {
+			log.Panic(err)
+		}
+		state.put(nameKey(action.Name), updated.Serialize())
+	}
+
+	state.write(batch, stateKey(nameUndoBucket, block.Hash))
+}
+
+// disconnectNames adds restoring the names from before the block to batch
+func (bc *Blockchain) disconnectNames(batch *leveldb.Batch, block *Block) {
+	bc.restoreState(batch, stateKey(nameUndoBucket, block.Hash))
+}
+
+// NewNameTransaction creates a transaction from a wallet address carrying
+// a name action, its fee pays for the name
+func NewNameTransaction(from string, action NameAction, selection CoinSelection, UTXOSet *UTXOSet) *Transaction {
+	if err := action.check(); err != nil {
+		log.Panic(err)
+	}
+	wallets, err := NewWallets()
+	if err != nil {
+		log.Panic(err)
+	}
+	wallet := wallets.GetWallet(from)
+	pubKeyHash := AddressToPubKeyHash(from)
+
+	inputs, prevOutputs, value := fundFee(nil, nil, -action.Fee(), 1, pubKeyHash, selection, UTXOSet)
+	inputs[0].Name = &action
+
+	var outputs []TXOutput
+	if change := value - selection.FeeRate.Fee(len(inputs), 1); change > 0 {
+		outputs = append(outputs, *NewTXOutput(change, from))
+	}
+
+	tx := Transaction{nil, inputs, outputs, 0}
+	tx.ID = tx.Hash()
+	tx.SignPrevOutputs(wallet.PrivateKey, prevOutputs, SigHashAll)
+
+	return &tx
+}
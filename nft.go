@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+const nftBucket = "nft"
+const nftIDLen = sha256.Size
+const maxNFTURILen = 256
+
+// NFT is a unique token held by an output. It is minted by a transaction
+// whose first input spends a coin of the Issuer key, and afterwards moves
+// unchanged from input to output, it can never be split, copied or burnt.
+type NFT struct {
+	ID           []byte
+	Issuer       []byte
+	MetadataHash []byte
+	URI          string
+}
+
+// NFTLocation is the output currently holding an NFT, kept in the NFT
+// index under the token ID
+type NFTLocation struct {
+	TxID       []byte
+	Index      int
+	PubKeyHash []byte
+}
+
+// NFTCoin is a spendable output holding an NFT
+type NFTCoin struct {
+	Coin
+	NFT NFT
+}
+
+// getNFTKey returns the NFT index key of a hex token ID
+func getNFTKey(nftID string) []byte {
+	return []byte(nftBucket + "_" + nftID)
+}
+
+// NewNFTID derives the ID of the token minted into output outIdx of a
+// transaction whose first input spends txID:vout
+func NewNFTID(txID []byte, vout, outIdx int) []byte {
+	data := append([]byte("nft"), txID...)
+	data = binary.BigEndian.AppendUint64(data, uint64(vout))
+	data = binary.BigEndian.AppendUint64(data, uint64(outIdx))
+	hash := sha256.Sum256(data)
+
+	return hash[:]
+}
+
+// IsNFT checks whether the output holds an NFT
+func (out *TXOutput) IsNFT() bool {
+	return out.NFT != nil
+}
+
+// checkNFT enforces the NFT rules on an output
+func (out *TXOutput) checkNFT() error {
+	if out.IsData() || out.IsAsset() {
+		return errors.New("NFT outputs cannot carry data or assets")
+	}
+	if len(out.NFT.ID) != nftIDLen || len(out.NFT.MetadataHash) != sha256.Size {
+		return fmt.Errorf("NFT ID and metadata hash must be %d bytes", sha256.Size)
+	}
+	if len(out.NFT.Issuer) != pubKeyHashLen {
+		return errors.New("NFT issuer must be a public key hash")
+	}
+	if out.NFT.URI == "" || len(out.NFT.URI) > maxNFTURILen {
+		return fmt.Errorf("NFT URI must have between 1 and %d bytes", maxNFTURILen)
+	}
+
+	return nil
+}
+
+// equal checks whether two NFTs are the same token with the same metadata
+func (nft NFT) equal(other NFT) bool {
+	return bytes.Equal(nft.ID, other.ID) && bytes.Equal(nft.Issuer, other.Issuer) &&
+		bytes.Equal(nft.MetadataHash, other.MetadataHash) && nft.URI == other.URI
+}
+
+// CheckNFTs checks that every NFT the inputs hold reappears unchanged in
+// exactly one output and that any other NFT output is a valid mint: its ID
+// derives from the first input, which the issuer key signs
+func (bc *Blockchain) CheckNFTs(tx *Transaction, parents map[string]*Transaction) error {
+	if tx.IsCoinbase() {
+		for _, out := range tx.Vout {
+			if out.IsNFT() {
+				return errors.New("coinbase transactions cannot mint NFTs")
+			}
+		}
+
+		return nil
+	}
+
+	held := make(map[string]NFT)
+	for _, vin := range tx.Vin {
+		out, err := bc.FindSpentOutput(vin.Txid, vin.Vout, parents)
+		if err != nil {
+			return err
+		}
+		if out.IsNFT() {
+			held[hex.EncodeToString(out.NFT.ID)] = *out.NFT
+		}
+	}
+
+	moved := make(map[string]bool)
+	for outIdx, out := range tx.Vout {
+		if !out.IsNFT() {
+			continue
+		}
+		id := hex.EncodeToString(out.NFT.ID)
+		if moved[id] {
+			return fmt.Errorf("transaction %x duplicates NFT %s", tx.ID, id)
+		}
+		moved[id] = true
+
+		if nft, ok := held[id]; ok {
+			if !nft.equal(*out.NFT) {
+				return fmt.Errorf("transaction %x changes NFT %s", tx.ID, id)
+			}
+			continue
+		}
+
+		minter := tx.Vin[0]
+		if !bytes.Equal(out.NFT.ID, NewNFTID(minter.Txid, minter.Vout, outIdx)) {
+			return fmt.Errorf("transaction %x mints NFT %s under a foreign ID", tx.ID, id)
+		}
+		if len(minter.RedeemScript) > 0 || !bytes.Equal(HashPubKey(minter.PubKey), out.NFT.Issuer) {
+			return fmt.Errorf("NFT %s is not minted by its issuer key", id)
+		}
+	}
+
+	for id := range held {
+		if !moved[id] {
+			return fmt.Errorf("transaction %x burns NFT %s", tx.ID, id)
+		}
+	}
+
+	return nil
+}
+
+// Serialize serializes the NFT location
+func (loc NFTLocation) Serialize() []byte {
+	var buff bytes.Buffer
+
+	enc := gob.NewEncoder(&buff)
+	err := enc.Encode(loc)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return buff.Bytes()
+}
+
+// DeserializeNFTLocation deserializes an NFT location
+func DeserializeNFTLocation(data []byte) NFTLocation {
+	var loc NFTLocation
+
+	dec := gob.NewDecoder(bytes.NewReader(data))
+	err := dec.Decode(&loc)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return loc
+}
+
+// indexNFTs records the outputs of a transaction holding NFTs in the NFT
+// index, replacing the outputs they were spent from
+func indexNFTs(batch *leveldb.Batch, txID []byte, outs TXOutputs) {
+	for i, out := range outs.Outputs {
+		if out.IsNFT() {
+			loc := NFTLocation{txID, outs.Index(i), out.PubKeyHash}
+			batch.Put(getNFTKey(hex.EncodeToString(out.NFT.ID)), loc.Serialize())
+		}
+	}
+}
+
+// FindNFTOwner looks up the output holding an NFT in the NFT index
+func (u UTXOSet) FindNFTOwner(nftID []byte) (*NFTLocation, error) {
+	data, err := u.Blockchain.db.Get(getNFTKey(hex.EncodeToString(nftID)), nil)
+	if err == leveldb.ErrNotFound {
+		return nil, fmt.Errorf("NFT %x is not minted", nftID)
+	}
+	if err != nil {
+		log.Panic(err)
+	}
+	loc := DeserializeNFTLocation(data)
+
+	return &loc, nil
+}
+
+// FindNFTs returns the mature outputs locked to a public key hash holding
+// NFTs that no pending transaction spends
+func (u UTXOSet) FindNFTs(pubKeyHash []byte) []NFTCoin {
+	var coins []NFTCoin
+	db := u.Blockchain.db
+	pending := Mempool{u.Blockchain}.SpentOutputs()
+	height := u.Blockchain.GetBestHeight() + 1
+
+	iter := db.NewIterator(nil, nil)
+	for iter.Next() {
+		key := iter.Key()
+		// Check if the key has the chainstate prefix
+		if len(key) > len(utxoBucket) && string(key[:len(utxoBucket)]) == utxoBucket {
+			txID := string(key[len(utxoBucket)+1:])
+			outs := DeserializeOutputs(iter.Value())
+			if !outs.IsMature(height) {
+				continue
+			}
+
+			for i, out := range outs.Outputs {
+				if _, ok := pending[txID+":"+strconv.Itoa(outs.Index(i))]; ok {
+					continue
+				}
+				if out.IsNFT() && out.IsLockedWithKey(pubKeyHash) {
+					coins = append(coins, NFTCoin{Coin{txID, outs.Index(i), out.Value}, *out.NFT})
+				}
+			}
+		}
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		log.Panic(err)
+	}
+
+	return coins
+}
+
+// NewMintTransaction mints an NFT to an address. The issuer's wallet
+// address pays the fee with the first input, which makes it the issuer.
+func NewMintTransaction(from, to string, metadataHash []byte, uri string, selection CoinSelection, UTXOSet *UTXOSet) *Transaction {
+	wallets, err := NewWallets()
+	if err != nil {
+		log.Panic(err)
+	}
+	wallet := wallets.GetWallet(from)
+	pubKeyHash := AddressToPubKeyHash(from)
+
+	inputs, prevOutputs, value := fundFee(nil, nil, 0, 2, pubKeyHash, selection, UTXOSet)
+
+	token := NewTXOutput(0, to)
+	token.NFT = &NFT{NewNFTID(inputs[0].Txid, inputs[0].Vout, 0), pubKeyHash, metadataHash, uri}
+	if err := token.checkNFT(); err != nil {
+		log.Panic(err)
+	}
+	outputs := []TXOutput{*token}
+	if change := value - selection.FeeRate.Fee(len(inputs), 2); change > 0 {
+		outputs = append(outputs, *NewTXOutput(change, from))
+	}
+
+	tx := Transaction{nil, inputs, outputs, 0}
+	tx.ID = tx.Hash()
+	tx.SignPrevOutputs(wallet.PrivateKey, prevOutputs, SigHashAll)
+
+	return &tx
+}
+
+// NewNFTTransfer moves an NFT held by a wallet address to another address,
+// coins of the sender pay the fee
+func NewNFTTransfer(from, to string, nftID []byte, selection CoinSelection, UTXOSet *UTXOSet) *Transaction {
+	wallets, err := NewWallets()
+	if err != nil {
+		log.Panic(err)
+	}
+	wallet := wallets.GetWallet(from)
+	pubKeyHash := AddressToPubKeyHash(from)
+
+	var held *NFTCoin
+	for _, coin := range UTXOSet.FindNFTs(pubKeyHash) {
+		if bytes.Equal(coin.NFT.ID, nftID) {
+			held = &coin
+			break
+		}
+	}
+	if held == nil {
+		log.Panicf("ERROR: %s does not hold NFT %x", from, nftID)
+	}
+
+	txID, err := hex.DecodeString(held.TxID)
+	if err != nil {
+		log.Panic(err)
+	}
+	inputs := []TXInput{{Txid: txID, Vout: held.Index}}
+	prevOutputs := []TXOutput{{held.Value, pubKeyHash, nil, nil, &held.NFT}}
+	inputs, prevOutputs, value := fundFee(inputs, prevOutputs, held.Value, 2, pubKeyHash, selection, UTXOSet)
+
+	token := NewTXOutput(0, to)
+	token.NFT = &held.NFT
+	outputs := []TXOutput{*token}
+	if change := value - selection.FeeRate.Fee(len(inputs), 2); change > 0 {
+		outputs = append(outputs, *NewTXOutput(change, from))
+	}
+
+	tx := Transaction{nil, inputs, outputs, 0}
+	tx.ID = tx.Hash()
+	tx.SignPrevOutputs(wallet.PrivateKey, prevOutputs, SigHashAll)
+
+	return &tx
+}
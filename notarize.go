@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+)
+
+// notarizationTag prefixes document hashes in data outputs
+var notarizationTag = []byte("NOTARY")
+
+// NotarizationPayload returns the data output payload for a document
+func NotarizationPayload(document []byte) []byte {
+	hash := sha256.Sum256(document)
+
+	return append(append([]byte{}, notarizationTag...), hash[:]...)
+}
+
+// NewDataTransaction creates a transaction anchoring data on chain. It
+// spends one of the sender's outputs and returns the whole value as change.
+func NewDataTransaction(from string, data []byte, UTXOSet *UTXOSet) *Transaction {
+	var inputs []TXInput
+
+	wallets, err := NewWallets()
+	if err != nil {
+		log.Panic(err)
+	}
+	wallet := wallets.GetWallet(from)
+	pubKeyHash := HashPubKey(wallet.PublicKey)
+	acc, validOutputs := UTXOSet.FindSpendableOutputs(pubKeyHash, 1)
+
+	if acc < 1 {
+		log.Panic("ERROR: Not enough funds")
+	}
+
+	for txid, outs := range validOutputs {
+		txID, err := hex.DecodeString(txid)
+		if err != nil {
+			log.Panic(err)
+		}
+
+		for _, out := range outs {
+			input := TXInput{Txid: txID, Vout: out, PubKey: wallet.PublicKey}
+			inputs = append(inputs, input)
+		}
+	}
+
+	dataOutput, err := NewDataOutput(data)
+	if err != nil {
+		log.Panic(err)
+	}
+	outputs := []TXOutput{*dataOutput, *NewTXOutput(acc, from)}
+
+	tx := Transaction{nil, inputs, outputs, 0}
+	tx.ID = tx.Hash()
+	UTXOSet.Blockchain.SignTransaction(&tx, wallet.PrivateKey)
+
+	return &tx
+}
+
+// FindData returns the earliest block and the transaction whose data
+// output carries exactly data
+func (bc *Blockchain) FindData(data []byte) (*Block, *Transaction, error) {
+	var foundBlock *Block
+	var foundTx *Transaction
+	bci := bc.Iterator()
+
+	// Walk the whole chain so an older anchor wins over a later duplicate
+	for {
+		block := bci.Next()
+
+		for _, tx := range block.Transactions {
+			for _, out := range tx.Vout {
+				if out.IsData() && bytes.Equal(out.Data, data) {
+					foundBlock, foundTx = block, tx
+				}
+			}
+		}
+
+		if len(block.PrevBlockHash) == 0 {
+			break
+		}
+	}
+
+	if foundBlock == nil {
+		return nil, nil, errors.New("data is not anchored in the blockchain")
+	}
+
+	return foundBlock, foundTx, nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import "fmt"
+
+// ChainParams holds the consensus parameters of the chain
+type ChainParams struct {
+	// CoinbaseMaturity is the number of blocks that must be mined on top
+	// of a coinbase before its outputs can be spent
+	CoinbaseMaturity int
+
+	// MaxBlockSize and MaxTxSize bound serialized blocks and transactions
+	// in bytes
+	MaxBlockSize int
+	MaxTxSize    int
+
+	// MaxTxInputs and MaxTxOutputs bound the inputs and outputs of a
+	// transaction
+	MaxTxInputs  int
+	MaxTxOutputs int
+
+	// MaxBlockSigOps bounds the signature checks verifying a block takes,
+	// a single transaction may not need more either
+	MaxBlockSigOps int
+
+	// MaxContractGas bounds the gas limit of a contract transaction
+	MaxContractGas int
+
+	// StakeThreshold is the stake a block has to exceed to be valid
+	StakeThreshold int
+
+	// Subsidy is what the coinbase of a block pays on top of the fees of
+	// its transactions
+	Subsidy int
+}
+
+// DefaultChainParams are the parameters used by the node
+var DefaultChainParams = ChainParams{
+	CoinbaseMaturity: 10,
+	MaxBlockSize:     1 << 20,
+	MaxTxSize:        100000,
+	MaxTxInputs:      1000,
+	MaxTxOutputs:     1000,
+	MaxBlockSigOps:   4000,
+	MaxContractGas:   1000000,
+	StakeThreshold:   50,
+	Subsidy:          10,
+}
+
+// activeChainParams are the parameters in effect for the next block of the
+// open blockchain, governance may have changed them from the defaults
+var activeChainParams = DefaultChainParams
+
+// chainParams returns the active chain parameters
+func chainParams() ChainParams {
+	return activeChainParams
+}
+
+// governableParam is a parameter governance can change. Limits the decoding
+// of stored blocks and transactions depends on can only be raised, or blocks
+// valid before a change could no longer be read. The stake threshold can
+// only be raised too, light clients do not see governance and hold every
+// header to the default one.
+type governableParam struct {
+	field     func(*ChainParams) *int
+	min       int
+	raiseOnly bool
+}
+
+// governableParams are the parameters governance can change by name
+var governableParams = map[string]governableParam{
+	"coinbasematurity": {func(p *ChainParams) *int { return &p.CoinbaseMaturity }, 0, false},
+	"maxblocksize":     {func(p *ChainParams) *int { return &p.MaxBlockSize }, 1, true},
+	"maxtxsize":        {func(p *ChainParams) *int { return &p.MaxTxSize }, 1, true},
+	"maxtxinputs":      {func(p *ChainParams) *int { return &p.MaxTxInputs }, 1, false},
+	"maxtxoutputs":     {func(p *ChainParams) *int { return &p.MaxTxOutputs }, 1, false},
+	"maxblocksigops":   {func(p *ChainParams) *int { return &p.MaxBlockSigOps }, 1, false},
+	"maxcontractgas":   {func(p *ChainParams) *int { return &p.MaxContractGas }, 1, false},
+	"stakethreshold":   {func(p *ChainParams) *int { return &p.StakeThreshold }, 0, true},
+	"subsidy":          {func(p *ChainParams) *int { return &p.Subsidy }, 0, false},
+}
+
+// checkParamChange checks that governance may set the parameter name to
+// value while params are in effect
+func checkParamChange(params ChainParams, name string, value int) error {
+	param, ok := governableParams[name]
+	if !ok {
+		return fmt.Errorf("unknown chain parameter %s", name)
+	}
+	if value < param.min {
+		return fmt.Errorf("%s must be at least %d", name, param.min)
+	}
+	if param.raiseOnly && value < *param.field(&params) {
+		return fmt.Errorf("%s can only be raised", name)
+	}
+
+	return nil
+}
+
+// set changes the parameter name, limits that can only be raised keep the
+// larger value
+func (params *ChainParams) set(name string, value int) {
+	param, ok := governableParams[name]
+	if !ok {
+		return
+	}
+	field := param.field(params)
+	if param.raiseOnly && value < *field {
+		return
+	}
+	*field = value
+}
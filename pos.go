@@ -3,32 +3,24 @@ package main
 import (
 	"fmt"
 	"math/big"
-	"bytes"
 	"crypto/sha256"
 )
 
 // ProofOfStake represents a proof-of-stake
 type ProofOfStake struct {
-	block *Block
+	block     *Block
+	threshold int64
 }
 
-// NewProofOfStake creates and returns a ProofOfStake
-func NewProofOfStake(b *Block) *ProofOfStake {
-	pos := &ProofOfStake{b}
+// NewProofOfStake creates and returns a ProofOfStake, the block needs a
+// stake above threshold
+func NewProofOfStake(b *Block, threshold int) *ProofOfStake {
+	pos := &ProofOfStake{b, int64(threshold)}
 	return pos
 }
 
 func (pos *ProofOfStake) prepareData() []byte {
-	data := bytes.Join(
-		[][]byte{
-			pos.block.PrevBlockHash,
-			pos.block.HashTransactions(),
-			IntToHex(pos.block.Timestamp),
-		},
-		[]byte{},
-	)
-
-	return data
+	return pos.block.Header().hashData()
 }
 
 // Run performs a placeholder proof-of-stake validation
@@ -45,12 +37,12 @@ func (pos *ProofOfStake) Run() []byte {
 	hashInt.SetBytes(hash[:])
 
 	// Ensure stake meets the threshold
-	if pos.block.Stake < 50 {
+	if pos.block.Stake < pos.threshold {
 		fmt.Println("Block rejected due to insufficient stake")
 	}
 
-	// If threshold is above 50, always return true
-	if pos.block.Stake > 50 {
+	// If stake is above the threshold, always return true
+	if pos.block.Stake > pos.threshold {
 		fmt.Println("Block created successfully")
 	}
 	
@@ -66,12 +58,12 @@ func (pos *ProofOfStake) Validate() bool {
     hashInt.SetBytes(hash[:])
 
     // Ensure stake meets the threshold
-    if pos.block.Stake < 50 {
+    if pos.block.Stake < pos.threshold {
         return false
     }
 
-    // If threshold is above 50, always return true
-    if pos.block.Stake > 50 {
+    // If stake is above the threshold, always return true
+    if pos.block.Stake > pos.threshold {
         return true
     }
 
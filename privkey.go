@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+// privKeyVersion prefixes exported private keys, privKeyCompressed follows
+// the key of wallets using compressed public keys
+const privKeyVersion = byte(0x80)
+const privKeyCompressed = byte(0x01)
+
+// EncodePrivateKey exports the private key of a wallet as the Base58 text of
+// the version, the key, the compressed flag and a checksum
+func EncodePrivateKey(wallet *Wallet) (string, error) {
+	if wallet.PrivateKey.D == nil {
+		return "", ErrWalletLocked
+	}
+
+	payload := append([]byte{privKeyVersion}, wallet.PrivateKey.D.FillBytes(make([]byte, coordinateLen))...)
+	if len(wallet.PublicKey) == compressedPubKeyLen {
+		payload = append(payload, privKeyCompressed)
+	}
+	payload = append(payload, checksum(payload)...)
+
+	return string(Base58Encode(payload)), nil
+}
+
+// DecodePrivateKey imports a private key exported by EncodePrivateKey as a
+// wallet using the public key encoding the key was exported with
+func DecodePrivateKey(encoded string) (*Wallet, error) {
+	payload := Base58Decode([]byte(encoded))
+	if len(payload) != 1+coordinateLen+addressChecksumLen && len(payload) != 2+coordinateLen+addressChecksumLen {
+		return nil, errors.New("private key has the wrong length")
+	}
+	body := payload[:len(payload)-addressChecksumLen]
+	if !bytes.Equal(checksum(body), payload[len(body):]) {
+		return nil, errors.New("private key checksum does not match")
+	}
+	if body[0] != privKeyVersion {
+		return nil, fmt.Errorf("private key has unknown version %d", body[0])
+	}
+	compressed := len(body) == 2+coordinateLen
+	if compressed && body[len(body)-1] != privKeyCompressed {
+		return nil, errors.New("private key has an unknown flag")
+	}
+
+	curve := elliptic.P256()
+	d := new(big.Int).SetBytes(body[1 : 1+coordinateLen])
+	if d.Sign() == 0 || d.Cmp(curve.Params().N) >= 0 {
+		return nil, errors.New("private key is out of range")
+	}
+	private := ecdsa.PrivateKey{D: d}
+	private.PublicKey.Curve = curve
+	private.PublicKey.X, private.PublicKey.Y = curve.ScalarBaseMult(body[1 : 1+coordinateLen])
+
+	public := encodeLegacyPubKey(&private.PublicKey)
+	if compressed {
+		public = encodePubKey(&private.PublicKey)
+	}
+
+	return &Wallet{private, public, nil, ""}, nil
+}
+
+// ImportKey adds a wallet to the wallets and returns its address, added is
+// false when the wallet file has the key already
+func (ws *Wallets) ImportKey(wallet *Wallet) (address string, added bool) {
+	address = fmt.Sprintf("%s", wallet.GetAddress())
+	if _, ok := ws.Wallets[address]; ok {
+		return address, false
+	}
+
+	ws.Wallets[address] = wallet
+	return address, true
+}
+
+// DumpKeys writes the private keys of the wallet file to w, one exported
+// key per line with its address and derivation path. The HD mnemonic is
+// written as a comment for restorewallet.
+func (ws Wallets) DumpKeys(w io.Writer) error {
+	var addresses []string
+	for address := range ws.Wallets {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	fmt.Fprintf(w, "# Wallet dump created %s\n", time.Now().UTC().Format(time.RFC3339))
+	if ws.HD != nil {
+		if ws.HD.Mnemonic == "" {
+			return ErrWalletLocked
+		}
+		fmt.Fprintf(w, "# mnemonic: %s\n", ws.HD.Mnemonic)
+	}
+	for _, address := range addresses {
+		wallet := ws.Wallets[address]
+		key, err := EncodePrivateKey(wallet)
+		if err != nil {
+			return err
+		}
+
+		line := fmt.Sprintf("%s addr=%s", key, address)
+		if wallet.Path != "" {
+			line += " path=" + wallet.Path
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReadKeyDump reads the wallets of a dump written by DumpKeys, comment and
+// empty lines are skipped
+func ReadKeyDump(r io.Reader) ([]*Wallet, error) {
+	var wallets []*Wallet
+
+	scanner := bufio.NewScanner(r)
+	for number := 1; scanner.Scan(); number++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		wallet, err := DecodePrivateKey(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", number, err)
+		}
+		for _, field := range fields[1:] {
+			if strings.HasPrefix(field, "path=") {
+				wallet.Path = strings.TrimPrefix(field, "path=")
+			}
+		}
+		wallets = append(wallets, wallet)
+	}
+
+	return wallets, scanner.Err()
+}
+
+// ReceivedOutputs counts the outputs an address was ever paid and their
+// value
+type ReceivedOutputs struct {
+	Count int
+	Value int
+}
+
+// Rescan walks the chain for the outputs ever paid to the addresses
+func (bc *Blockchain) Rescan(addresses []string) map[string]ReceivedOutputs {
+	watched := make(map[string]string)
+	for _, address := range addresses {
+		watched[hex.EncodeToString(AddressToPubKeyHash(address))] = address
+	}
+
+	received := make(map[string]ReceivedOutputs)
+	bci := bc.Iterator()
+	for {
+		block := bci.Next()
+
+		for _, tx := range block.Transactions {
+			for _, out := range tx.Vout {
+				address, ok := watched[hex.EncodeToString(out.PubKeyHash)]
+				if !ok || out.IsData() {
+					continue
+				}
+				outputs := received[address]
+				outputs.Count++
+				outputs.Value += out.Value
+				received[address] = outputs
+			}
+		}
+
+		if len(block.PrevBlockHash) == 0 {
+			break
+		}
+	}
+
+	return received
+}
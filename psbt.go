@@ -0,0 +1,323 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// psbtMagic prefixes serialized partially signed transactions so they can
+// be told apart from plain serialized transactions
+const psbtMagic = "psbt\xff"
+
+// PartialTransaction is an unsigned or partially signed transaction
+// together with the outputs its inputs spend, everything a signer needs
+// without access to the blockchain. MuSig holds the signing session of
+// each input spending an aggregated Schnorr key.
+type PartialTransaction struct {
+	Tx          Transaction
+	PrevOutputs []TXOutput
+	MuSig       map[int]*MuSigSession
+}
+
+// NewRawTransaction selects coins of a wallet or multisig address and
+// builds an unsigned transaction paying the recipients. It only needs the
+// address, not its keys. Script addresses need their redeem script, and
+// aggregated Schnorr addresses their participant keys, in the local wallet
+// file.
+func NewRawTransaction(from string, payments []Payment, lockTime int64, sequence uint32, selection CoinSelection, UTXOSet *UTXOSet) *PartialTransaction {
+	var inputs []TXInput
+	var outputs []TXOutput
+	var prevOutputs []TXOutput
+
+	amount := checkPayments(payments)
+
+	pubKeyHash := AddressToPubKeyHash(from)
+	var script *RedeemScript
+	var aggregateKeys [][]byte
+	if IsScriptAddress(from) {
+		wallets, err := NewWallets()
+		if err != nil {
+			log.Panic(err)
+		}
+		script, err = wallets.GetScript(from)
+		if err != nil {
+			log.Panic(err)
+		}
+		if script.Type != ScriptMultiSig && script.Type != ScriptSchnorr {
+			log.Panic("ERROR: Only multisig and Schnorr script addresses can be spent this way")
+		}
+		aggregateKeys = wallets.AggregateKeys[from]
+		if script.Type == ScriptSchnorr && len(aggregateKeys) == 0 {
+			log.Panicf("ERROR: No participant keys for %s in the wallet", from)
+		}
+	}
+
+	coins, err := selection.Selector.Select(UTXOSet.FindCoins(pubKeyHash), amount, len(payments), selection.FeeRate)
+	if err != nil {
+		log.Panic("ERROR: Not enough funds")
+	}
+
+	// Build a list of unsigned inputs
+	acc := 0
+	for _, coin := range coins {
+		txID, err := hex.DecodeString(coin.TxID)
+		if err != nil {
+			log.Panic(err)
+		}
+
+		input := TXInput{Txid: txID, Vout: coin.Index, Sequence: sequence}
+		if script != nil {
+			input.RedeemScript = script.Serialize()
+		}
+		if script != nil && script.Type == ScriptMultiSig {
+			input.Witness = make([][]byte, len(script.PubKeys))
+		}
+		inputs = append(inputs, input)
+		prevOutputs = append(prevOutputs, TXOutput{coin.Value, pubKeyHash, nil, nil, nil})
+		acc += coin.Value
+	}
+
+	// Build a list of outputs
+	for _, payment := range payments {
+		outputs = append(outputs, *NewTXOutput(payment.Amount, payment.Address))
+	}
+	change := acc - amount - selection.FeeRate.Fee(len(inputs), len(payments)+1)
+	if change > 0 {
+		outputs = append(outputs, *NewTXOutput(change, from)) // a change
+	}
+
+	tx := Transaction{nil, inputs, outputs, lockTime}
+	tx.ID = tx.Hash()
+
+	sessions := make(map[int]*MuSigSession)
+	if script != nil && script.Type == ScriptSchnorr {
+		for inID := range inputs {
+			session, err := NewMuSigSession(aggregateKeys)
+			if err != nil {
+				log.Panic(err)
+			}
+			sessions[inID] = session
+		}
+	}
+
+	return &PartialTransaction{tx, prevOutputs, sessions}
+}
+
+// Serialize returns the serialized partial transaction
+func (pt PartialTransaction) Serialize() []byte {
+	var encoded bytes.Buffer
+
+	encoded.WriteString(psbtMagic)
+	enc := gob.NewEncoder(&encoded)
+	err := enc.Encode(pt)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return encoded.Bytes()
+}
+
+// IsPartialTransaction checks whether data is a serialized partial
+// transaction
+func IsPartialTransaction(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(psbtMagic))
+}
+
+// DeserializePartialTransaction decodes a serialized partial transaction
+func DeserializePartialTransaction(data []byte) (*PartialTransaction, error) {
+	if !IsPartialTransaction(data) {
+		return nil, errors.New("not a partially signed transaction")
+	}
+
+	var pt PartialTransaction
+	decoder := gob.NewDecoder(bytes.NewReader(data[len(psbtMagic):]))
+	if err := decoder.Decode(&pt); err != nil {
+		return nil, err
+	}
+	if len(pt.PrevOutputs) != len(pt.Tx.Vin) {
+		return nil, errors.New("partially signed transaction lacks previous outputs")
+	}
+
+	return &pt, nil
+}
+
+// Sign adds the signatures of every wallet key that can unlock an input
+// and returns the number of keys that signed
+func (pt *PartialTransaction) Sign(wallets *Wallets, hashType byte) int {
+	signers := make(map[string]*Wallet)
+	addSigner := func(wallet *Wallet) {
+		if wallet != nil {
+			signers[hex.EncodeToString(wallet.PublicKey)] = wallet
+		}
+	}
+
+	for inID, vin := range pt.Tx.Vin {
+		if len(vin.RedeemScript) == 0 {
+			addSigner(wallets.FindByPubKeyHash(pt.PrevOutputs[inID].PubKeyHash))
+			continue
+		}
+
+		script, err := DeserializeRedeemScript(vin.RedeemScript)
+		if err != nil {
+			log.Panic(err)
+		}
+		for _, pubKey := range script.PubKeys {
+			addSigner(wallets.FindByPubKey(pubKey))
+		}
+		if script.Type == ScriptHTLC || script.Type == ScriptRevocable {
+			addSigner(wallets.FindByPubKeyHash(script.RecipientHash))
+			addSigner(wallets.FindByPubKeyHash(script.RefundHash))
+		}
+	}
+
+	for _, wallet := range signers {
+		pt.Tx.SignPrevOutputs(wallet.PrivateKey, pt.PrevOutputs, hashType)
+	}
+
+	return len(signers) + pt.signMuSig(wallets)
+}
+
+// signMuSig takes the MuSig sessions as far as the local keys allow: it
+// publishes nonces for local participants, signs once every nonce is known
+// and aggregates the signature once every participant signed. Secret nonces
+// wait in the wallet between the rounds and are deleted after signing, the
+// wallet must be saved afterwards. MuSig inputs always sign with
+// SigHashAll so all partial signatures commit to the same hash. It returns
+// the number of local participants that took part.
+func (pt *PartialTransaction) signMuSig(wallets *Wallets) int {
+	participants := 0
+
+	for inID, session := range pt.MuSig {
+		aggKey, msg := pt.muSigMessage(inID, session)
+
+		for _, key := range session.Keys {
+			wallet := wallets.FindByPubKey(key)
+			if wallet == nil {
+				continue
+			}
+			participants++
+
+			nonceID := fmt.Sprintf("%x:%d:%x", pt.Tx.ID, inID, key)
+			if _, ok := session.Nonces[hex.EncodeToString(key)]; !ok {
+				wallets.Nonces[nonceID] = session.AddNonce(key)
+			}
+		}
+
+		if !session.HasAllNonces() {
+			continue
+		}
+		for _, key := range session.Keys {
+			wallet := wallets.FindByPubKey(key)
+			if wallet == nil {
+				continue
+			}
+			if _, ok := session.PartialSigs[hex.EncodeToString(key)]; ok {
+				continue
+			}
+
+			if wallet.PrivateKey.D == nil {
+				log.Panic(ErrWalletLocked)
+			}
+			nonceID := fmt.Sprintf("%x:%d:%x", pt.Tx.ID, inID, key)
+			secretNonce, ok := wallets.Nonces[nonceID]
+			if !ok {
+				log.Panicf("ERROR: The secret nonce of %x for input %d is lost, start a new transaction", key, inID)
+			}
+			if err := session.PartialSign(wallet.PrivateKey, secretNonce, aggKey, msg); err != nil {
+				log.Panic(err)
+			}
+			// A nonce used twice with different challenges leaks the key
+			delete(wallets.Nonces, nonceID)
+			delete(wallets.EncryptedNonces, nonceID)
+		}
+	}
+
+	pt.finalizeMuSig()
+	return participants
+}
+
+// muSigMessage returns the aggregated key of a MuSig input and the
+// signature hash its participants sign
+func (pt PartialTransaction) muSigMessage(inID int, session *MuSigSession) ([]byte, []byte) {
+	script, err := DeserializeRedeemScript(pt.Tx.Vin[inID].RedeemScript)
+	if err != nil {
+		log.Panic(err)
+	}
+	aggKey, err := AggregateKeys(session.Keys)
+	if err != nil {
+		log.Panic(err)
+	}
+	if script.Type != ScriptSchnorr || !bytes.Equal(aggKey, script.PubKeys[0]) {
+		log.Panicf("ERROR: Participant keys of input %d do not aggregate to its key", inID)
+	}
+
+	msg := pt.Tx.SignatureHash(inID, pt.PrevOutputs[inID].PubKeyHash, SigHashAll)
+	return aggKey, msg
+}
+
+// finalizeMuSig places the aggregated signature of every session that has
+// all partial signatures into the witness of its input
+func (pt *PartialTransaction) finalizeMuSig() {
+	for inID, session := range pt.MuSig {
+		if !session.HasAllPartialSigs() || len(pt.Tx.Vin[inID].Witness) > 0 {
+			continue
+		}
+
+		aggKey, msg := pt.muSigMessage(inID, session)
+		signature, err := session.Aggregate(aggKey, msg)
+		if err != nil {
+			log.Panic(err)
+		}
+		pt.Tx.Vin[inID].Witness = [][]byte{append(signature, SigHashAll)}
+	}
+}
+
+// Combine merges the signatures of another copy of the same transaction
+func (pt *PartialTransaction) Combine(other *PartialTransaction) error {
+	if !bytes.Equal(pt.Tx.ID, other.Tx.ID) || len(pt.Tx.Vin) != len(other.Tx.Vin) {
+		return errors.New("partially signed transactions differ")
+	}
+
+	for inID, session := range other.MuSig {
+		if pt.MuSig == nil {
+			pt.MuSig = make(map[int]*MuSigSession)
+		}
+		if _, ok := pt.MuSig[inID]; !ok {
+			pt.MuSig[inID] = session
+			continue
+		}
+		pt.MuSig[inID].Merge(session)
+	}
+
+	for inID, vin := range pt.Tx.Vin {
+		otherVin := other.Tx.Vin[inID]
+
+		if len(vin.Signature) == 0 && len(otherVin.Signature) > 0 {
+			pt.Tx.Vin[inID].Signature = otherVin.Signature
+			pt.Tx.Vin[inID].PubKey = otherVin.PubKey
+		}
+		if len(vin.Witness) != len(otherVin.Witness) {
+			if len(vin.Witness) == 0 || len(vin.Witness[0]) == 0 {
+				pt.Tx.Vin[inID].Witness = otherVin.Witness
+			}
+			continue
+		}
+		for i, item := range otherVin.Witness {
+			if len(vin.Witness[i]) == 0 {
+				pt.Tx.Vin[inID].Witness[i] = item
+			}
+		}
+	}
+
+	pt.finalizeMuSig()
+	return nil
+}
+
+// IsComplete checks whether every input carries valid signatures
+func (pt PartialTransaction) IsComplete() bool {
+	return pt.Tx.VerifyPrevOutputs(pt.PrevOutputs)
+}
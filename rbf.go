@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sort"
+)
+
+// minFeeIncrement is how much more a replacement has to pay than the
+// transactions it replaces
+const minFeeIncrement = 1
+
+// IsReplaceable checks whether any input signals that the transaction may
+// be replaced while it is pending
+func (tx Transaction) IsReplaceable() bool {
+	for _, vin := range tx.Vin {
+		if vin.Sequence&sequenceReplaceable != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OutputValue returns the sum of the outputs, failing when it is out of
+// range
+func (tx Transaction) OutputValue() (int, error) {
+	value := 0
+	for _, out := range tx.Vout {
+		var err error
+		if value, err = addValue(value, out.Value); err != nil {
+			return 0, fmt.Errorf("transaction %x outputs: %s", tx.ID, err)
+		}
+	}
+
+	return value, nil
+}
+
+// Size returns the serialized size of the transaction in bytes
+func (tx Transaction) Size() int {
+	return len(tx.Serialize())
+}
+
+// Fee returns what the transaction pays to the miner, its inputs minus its
+// outputs
+func (u UTXOSet) Fee(tx *Transaction) int {
+	outputValue, err := tx.OutputValue()
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return u.InputValue(tx) - outputValue
+}
+
+// feeRateHigher reports whether fee/size is higher than otherFee/otherSize
+func feeRateHigher(fee, size, otherFee, otherSize int) bool {
+	return fee*otherSize > otherFee*size
+}
+
+// checkReplacement checks that tx, paying fee, may replace the pending
+// transactions it conflicts with: all of them signal replaceability, tx pays
+// more per byte than each of them and more in total than every evicted
+// transaction, the conflicts and their descendants, together
+func (m Mempool) checkReplacement(tx *Transaction, fee int, conflicts []*Transaction, evicted txPackage, entries map[string]*poolEntry) error {
+	size := tx.Size()
+
+	for _, conflict := range conflicts {
+		if !conflict.IsReplaceable() {
+			return fmt.Errorf("inputs are already spent by pending transaction %x, which is not replaceable", conflict.ID)
+		}
+
+		entry := entries[hex.EncodeToString(conflict.ID)]
+		if !feeRateHigher(fee, size, entry.fee, entry.size) {
+			return fmt.Errorf("replacement fee rate must be higher than that of %x", conflict.ID)
+		}
+	}
+	conflictFees, _ := evicted.feeAndSize(entries)
+
+	if fee < conflictFees+minFeeIncrement {
+		return fmt.Errorf("replacement must pay at least %d, it pays %d", conflictFees+minFeeIncrement, fee)
+	}
+
+	return nil
+}
+
+// NewFeeBump builds a replacement of a pending transaction paying at least
+// feeRate and more than the original. The extra fee comes out of the change
+// output, coins of the change address are added when the change is too
+// small. The replacement is signed with the wallet keys of the inputs.
+func NewFeeBump(tx *Transaction, feeRate FeeRate, wallets *Wallets, UTXOSet *UTXOSet) *Transaction {
+	if !tx.IsReplaceable() {
+		log.Panic("ERROR: Transaction does not signal replaceability")
+	}
+
+	// Inputs may spend outputs of pending parents, like CPFP children do
+	parents := Mempool{UTXOSet.Blockchain}.pool()
+	var inputs []TXInput
+	var prevOutputs []TXOutput
+	signers := make(map[string]*Wallet)
+	for _, vin := range tx.Vin {
+		out, err := UTXOSet.Blockchain.FindSpentOutput(vin.Txid, vin.Vout, parents)
+		if err != nil {
+			log.Panic(err)
+		}
+		wallet := wallets.FindByPubKeyHash(out.PubKeyHash)
+		if len(vin.RedeemScript) > 0 || wallet == nil {
+			log.Panic("ERROR: Only transactions spending wallet keys can be bumped")
+		}
+
+		signers[hex.EncodeToString(out.PubKeyHash)] = wallet
+		inputs = append(inputs, TXInput{Txid: vin.Txid, Vout: vin.Vout, PubKey: vin.PubKey, Sequence: vin.Sequence, Issuance: vin.Issuance, Contract: vin.Contract, Governance: vin.Governance, Name: vin.Name})
+		prevOutputs = append(prevOutputs, *out)
+	}
+
+	// The change goes back to the address of the first input
+	changePubKeyHash := prevOutputs[0].PubKeyHash
+	outputs := append([]TXOutput{}, tx.Vout...)
+	changeIdx := -1
+	for i, out := range outputs {
+		if !out.IsData() && !out.IsAsset() && !out.IsNFT() && bytes.Equal(out.PubKeyHash, changePubKeyHash) {
+			changeIdx = i
+		}
+	}
+	change := 0
+	if changeIdx >= 0 {
+		change = outputs[changeIdx].Value
+	}
+
+	oldFee := UTXOSet.Fee(tx)
+	newFee := feeRate.FeeForSize(tx.Size())
+	if newFee < oldFee+minFeeIncrement {
+		newFee = oldFee + minFeeIncrement
+	}
+	change -= newFee - oldFee
+
+	if change < 0 {
+		coins := UTXOSet.FindCoins(changePubKeyHash)
+		sort.SliceStable(coins, func(i, j int) bool { return coins[i].Value > coins[j].Value })
+
+		for _, coin := range coins {
+			if change >= 0 {
+				break
+			}
+			txID, err := hex.DecodeString(coin.TxID)
+			if err != nil {
+				log.Panic(err)
+			}
+
+			inputs = append(inputs, TXInput{Txid: txID, Vout: coin.Index, Sequence: sequenceReplaceable})
+			prevOutputs = append(prevOutputs, TXOutput{coin.Value, changePubKeyHash, nil, nil, nil})
+			change += coin.Value - (feeRate.Fee(1, 0) - feeRate.Fee(0, 0))
+		}
+		if change < 0 {
+			log.Panic("ERROR: Not enough funds to bump the fee")
+		}
+	}
+
+	switch {
+	case change > 0 && changeIdx >= 0:
+		outputs[changeIdx].Value = change
+	case change > 0:
+		outputs = append(outputs, TXOutput{change, changePubKeyHash, nil, nil, nil})
+	case changeIdx >= 0:
+		outputs = append(outputs[:changeIdx], outputs[changeIdx+1:]...)
+	}
+
+	bump := Transaction{nil, inputs, outputs, tx.LockTime}
+	bump.ID = bump.Hash()
+	for _, wallet := range signers {
+		bump.SignPrevOutputs(wallet.PrivateKey, prevOutputs, SigHashAll)
+	}
+
+	return &bump
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"log"
+	"math/big"
+)
+
+const schnorrSigLen = 2 * coordinateLen
+
+// schnorrCurve is the curve Schnorr signatures are made on, the same one
+// wallets use for ECDSA so a key can sign with either scheme
+var schnorrCurve = elliptic.P256()
+
+// taggedHash hashes data under a domain separation tag so that hashes made
+// for one purpose are never valid for another
+func taggedHash(tag string, data ...[]byte) []byte {
+	tagHash := sha256.Sum256([]byte(tag))
+
+	hasher := sha256.New()
+	hasher.Write(tagHash[:])
+	hasher.Write(tagHash[:])
+	for _, d := range data {
+		hasher.Write(d)
+	}
+
+	return hasher.Sum(nil)
+}
+
+// hashToScalar reduces a tagged hash to a scalar of the curve order
+func hashToScalar(tag string, data ...[]byte) *big.Int {
+	e := new(big.Int).SetBytes(taggedHash(tag, data...))
+
+	return e.Mod(e, schnorrCurve.Params().N)
+}
+
+// schnorrChallenge is the challenge e = H(R.x || P || m) of a signature
+// with nonce point R under the compressed public key P
+func schnorrChallenge(rx, pubKey, msg []byte) *big.Int {
+	return hashToScalar("Schnorr/challenge", rx, pubKey, msg)
+}
+
+// liftX returns the point with x coordinate x and an even y coordinate
+func liftX(x *big.Int) (*big.Int, *big.Int, bool) {
+	params := schnorrCurve.Params()
+	if x.Cmp(params.P) >= 0 {
+		return nil, nil, false
+	}
+
+	// y^2 = x^3 - 3x + b, P-256 has p = 3 mod 4 so y = (y^2)^((p+1)/4)
+	ySquared := new(big.Int).Exp(x, big.NewInt(3), params.P)
+	threeX := new(big.Int).Mul(x, big.NewInt(3))
+	ySquared.Sub(ySquared, threeX)
+	ySquared.Add(ySquared, params.B)
+	ySquared.Mod(ySquared, params.P)
+
+	exp := new(big.Int).Add(params.P, big.NewInt(1))
+	exp.Rsh(exp, 2)
+	y := new(big.Int).Exp(ySquared, exp, params.P)
+	if new(big.Int).Exp(y, big.NewInt(2), params.P).Cmp(ySquared) != 0 {
+		return nil, nil, false
+	}
+	if y.Bit(0) == 1 {
+		y.Sub(params.P, y)
+	}
+
+	return x, y, true
+}
+
+// negatePoint returns -P
+func negatePoint(x, y *big.Int) (*big.Int, *big.Int) {
+	return x, new(big.Int).Sub(schnorrCurve.Params().P, y)
+}
+
+// isInfinity checks for the point at infinity, which crypto/elliptic
+// represents as (0, 0)
+func isInfinity(x, y *big.Int) bool {
+	return x.Sign() == 0 && y.Sign() == 0
+}
+
+// parseSchnorrSignature splits a signature into R.x and s and checks both
+// are in range
+func parseSchnorrSignature(signature []byte) (*big.Int, *big.Int, bool) {
+	if len(signature) != schnorrSigLen {
+		return nil, nil, false
+	}
+
+	rx := new(big.Int).SetBytes(signature[:coordinateLen])
+	s := new(big.Int).SetBytes(signature[coordinateLen:])
+	if rx.Cmp(schnorrCurve.Params().P) >= 0 || s.Cmp(schnorrCurve.Params().N) >= 0 {
+		return nil, nil, false
+	}
+
+	return rx, s, true
+}
+
+// verifySchnorr checks s*G - e*P = R where R has an even y coordinate and
+// the x coordinate given in the signature
+func verifySchnorr(pubKey, msg, signature []byte) bool {
+	rawPubKey, err := decodePubKey(pubKey)
+	if err != nil || len(pubKey) != compressedPubKeyLen {
+		return false
+	}
+	rx, s, ok := parseSchnorrSignature(signature)
+	if !ok {
+		return false
+	}
+
+	e := schnorrChallenge(signature[:coordinateLen], pubKey, msg)
+	sGx, sGy := schnorrCurve.ScalarBaseMult(s.Bytes())
+	ePx, ePy := schnorrCurve.ScalarMult(rawPubKey.X, rawPubKey.Y, e.Bytes())
+	ePx, ePy = negatePoint(ePx, ePy)
+	x, y := schnorrCurve.Add(sGx, sGy, ePx, ePy)
+
+	if isInfinity(x, y) || y.Bit(0) == 1 {
+		return false
+	}
+
+	return x.Cmp(rx) == 0
+}
+
+// checkSchnorrSignature verifies a Schnorr signature carrying its hash type
+// in the last byte. With a batch the signature is only queued and checked
+// later together with the rest of the block.
+func checkSchnorrSignature(pubKey, signature []byte, hasher sigHasher, batch *SchnorrBatch) bool {
+	if len(signature) != schnorrSigLen+1 {
+		return false
+	}
+
+	hashType := signature[len(signature)-1]
+	if !isValidHashType(hashType) {
+		return false
+	}
+	hash := hasher(hashType)
+	if hash == nil {
+		return false
+	}
+
+	if batch != nil {
+		return batch.Add(pubKey, hash, signature[:schnorrSigLen])
+	}
+
+	return verifySchnorr(pubKey, hash, signature[:schnorrSigLen])
+}
+
+type schnorrBatchEntry struct {
+	pubKey    []byte
+	msg       []byte
+	signature []byte
+}
+
+// SchnorrBatch collects Schnorr signatures to verify them at once. A
+// random linear combination of all verification equations is checked, which
+// fails with overwhelming probability if any single signature is invalid.
+type SchnorrBatch struct {
+	entries []schnorrBatchEntry
+}
+
+// Add queues a signature, it reports false for malformed input that can
+// never verify
+func (b *SchnorrBatch) Add(pubKey, msg, signature []byte) bool {
+	if _, err := decodePubKey(pubKey); err != nil || len(pubKey) != compressedPubKeyLen {
+		return false
+	}
+	if _, _, ok := parseSchnorrSignature(signature); !ok {
+		return false
+	}
+
+	b.entries = append(b.entries, schnorrBatchEntry{pubKey, msg, signature})
+	return true
+}
+
+// Verify checks (sum a_i*s_i)*G = sum a_i*R_i + sum a_i*e_i*P_i with a_0 = 1
+// and the other a_i random
+func (b *SchnorrBatch) Verify() bool {
+	if len(b.entries) == 0 {
+		return true
+	}
+
+	curveOrder := schnorrCurve.Params().N
+	sum := new(big.Int)
+	var rhsX, rhsY *big.Int
+
+	for i, entry := range b.entries {
+		a := big.NewInt(1)
+		if i > 0 {
+			random := make([]byte, 16)
+			if _, err := rand.Read(random); err != nil {
+				log.Panic(err)
+			}
+			a.SetBytes(random)
+		}
+
+		rx, s, _ := parseSchnorrSignature(entry.signature)
+		Rx, Ry, ok := liftX(rx)
+		if !ok {
+			return false
+		}
+		rawPubKey, _ := decodePubKey(entry.pubKey)
+		e := schnorrChallenge(entry.signature[:coordinateLen], entry.pubKey, entry.msg)
+
+		sum.Add(sum, new(big.Int).Mul(a, s))
+		sum.Mod(sum, curveOrder)
+
+		ae := new(big.Int).Mul(a, e)
+		ae.Mod(ae, curveOrder)
+		aRx, aRy := schnorrCurve.ScalarMult(Rx, Ry, a.Bytes())
+		aePx, aePy := schnorrCurve.ScalarMult(rawPubKey.X, rawPubKey.Y, ae.Bytes())
+		termX, termY := schnorrCurve.Add(aRx, aRy, aePx, aePy)
+
+		if rhsX == nil {
+			rhsX, rhsY = termX, termY
+		} else {
+			rhsX, rhsY = schnorrCurve.Add(rhsX, rhsY, termX, termY)
+		}
+	}
+
+	lhsX, lhsY := schnorrCurve.ScalarBaseMult(sum.Bytes())
+
+	return lhsX.Cmp(rhsX) == 0 && lhsY.Cmp(rhsY) == 0
+}
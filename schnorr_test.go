@@ -0,0 +1,113 @@
+package main
+
+import (
+	"crypto/sha256"
+	"testing"
+)
+
+type testSignature struct {
+	pubKey    []byte
+	msg       []byte
+	signature []byte
+}
+
+func newTestSignatures(t *testing.T, n int) []testSignature {
+	t.Helper()
+
+	var signatures []testSignature
+	for i := 0; i < n; i++ {
+		privKeys, pubKeys := newTestKeys(t, 1+i%3)
+		msg := sha256.Sum256([]byte{byte(i)})
+		aggKey, signature := muSigSign(t, privKeys, pubKeys, msg[:])
+		signatures = append(signatures, testSignature{aggKey, msg[:], signature})
+	}
+
+	return signatures
+}
+
+func newTestBatch(t *testing.T, signatures []testSignature) *SchnorrBatch {
+	t.Helper()
+
+	batch := &SchnorrBatch{}
+	for _, sig := range signatures {
+		if !batch.Add(sig.pubKey, sig.msg, sig.signature) {
+			t.Fatal("batch refused a well-formed signature")
+		}
+	}
+
+	return batch
+}
+
+func TestSchnorrVerify(t *testing.T) {
+	for _, sig := range newTestSignatures(t, 4) {
+		if !verifySchnorr(sig.pubKey, sig.msg, sig.signature) {
+			t.Fatal("signature does not verify")
+		}
+
+		for _, i := range []int{0, coordinateLen - 1, coordinateLen, schnorrSigLen - 1} {
+			tampered := append([]byte{}, sig.signature...)
+			tampered[i] ^= 1
+			if verifySchnorr(sig.pubKey, sig.msg, tampered) {
+				t.Errorf("signature with byte %d flipped verifies", i)
+			}
+		}
+	}
+}
+
+func TestSchnorrBatchVerify(t *testing.T) {
+	signatures := newTestSignatures(t, 6)
+
+	if !newTestBatch(t, signatures).Verify() {
+		t.Fatal("batch of valid signatures does not verify")
+	}
+	if !(&SchnorrBatch{}).Verify() {
+		t.Fatal("empty batch does not verify")
+	}
+}
+
+func TestSchnorrBatchRejectsTamperedSignature(t *testing.T) {
+	signatures := newTestSignatures(t, 6)
+
+	// The first entry is weighted with 1, the others randomly, a bad
+	// signature has to fail the batch in either place
+	for _, bad := range []int{0, 3, 5} {
+		tampered := append([]testSignature{}, signatures...)
+		signature := append([]byte{}, tampered[bad].signature...)
+		signature[schnorrSigLen-1] ^= 1
+		tampered[bad].signature = signature
+
+		if newTestBatch(t, tampered).Verify() {
+			t.Errorf("batch with signature %d tampered verifies", bad)
+		}
+	}
+}
+
+func TestSchnorrBatchRejectsWrongMessage(t *testing.T) {
+	signatures := newTestSignatures(t, 4)
+	signatures[2].msg = signatures[1].msg
+
+	if newTestBatch(t, signatures).Verify() {
+		t.Fatal("batch with a signature over another message verifies")
+	}
+}
+
+func TestSchnorrBatchRejectsSwappedSignatures(t *testing.T) {
+	signatures := newTestSignatures(t, 4)
+	signatures[1].signature, signatures[2].signature = signatures[2].signature, signatures[1].signature
+
+	if newTestBatch(t, signatures).Verify() {
+		t.Fatal("batch with swapped signatures verifies")
+	}
+}
+
+func TestSchnorrBatchRefusesMalformedInput(t *testing.T) {
+	sig := newTestSignatures(t, 1)[0]
+	batch := &SchnorrBatch{}
+
+	if batch.Add(sig.pubKey, sig.msg, sig.signature[:schnorrSigLen-1]) {
+		t.Error("batch accepted a short signature")
+	}
+	if batch.Add(sig.pubKey[1:], sig.msg, sig.signature) {
+		t.Error("batch accepted a truncated public key")
+	}
+}
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const scriptVersion = byte(0x05)
+const maxMultiSigKeys = 16
+const pubKeyHashLen = 20
+
+// Redeem script types
+const (
+	ScriptMultiSig  = byte(0x01)
+	ScriptHTLC      = byte(0x02)
+	ScriptSchnorr   = byte(0x03)
+	ScriptRevocable = byte(0x04)
+)
+
+// RedeemScript describes the conditions that unlock a script-hash output.
+// The output itself only stores the hash of the serialized script, the
+// spender reveals the script in TXInput.RedeemScript.
+type RedeemScript struct {
+	Type byte
+
+	// Multisig: Required signatures out of PubKeys. Schnorr: the single,
+	// possibly aggregated, key in PubKeys
+	Required int
+	PubKeys  [][]byte
+
+	// HTLC: the recipient spends with the preimage of SecretHash, the
+	// refunder spends once LockTime has passed. Revocable: the refunder is
+	// the owner, who spends once the input waited LockTime blocks, and the
+	// recipient spends at once with the revocation secret.
+	SecretHash    []byte
+	RecipientHash []byte
+	RefundHash    []byte
+	LockTime      int64
+}
+
+// NewMultiSigScript creates an M-of-N multisignature redeem script
+func NewMultiSigScript(required int, pubKeys [][]byte) (*RedeemScript, error) {
+	if len(pubKeys) == 0 || len(pubKeys) > maxMultiSigKeys {
+		return nil, fmt.Errorf("multisig needs between 1 and %d public keys", maxMultiSigKeys)
+	}
+	if required < 1 || required > len(pubKeys) {
+		return nil, fmt.Errorf("required signatures must be between 1 and %d", len(pubKeys))
+	}
+	for i, pubKey := range pubKeys {
+		if _, err := decodePubKey(pubKey); err != nil {
+			return nil, fmt.Errorf("public key %d: %s", i, err)
+		}
+	}
+
+	return &RedeemScript{Type: ScriptMultiSig, Required: required, PubKeys: pubKeys}, nil
+}
+
+// NewSchnorrScript creates a script spent by one Schnorr signature under a
+// compressed public key. Aggregated MuSig keys look like any other key.
+func NewSchnorrScript(pubKey []byte) (*RedeemScript, error) {
+	if len(pubKey) != compressedPubKeyLen {
+		return nil, errors.New("Schnorr scripts need a compressed public key")
+	}
+	if _, err := decodePubKey(pubKey); err != nil {
+		return nil, err
+	}
+
+	return &RedeemScript{Type: ScriptSchnorr, PubKeys: [][]byte{pubKey}}, nil
+}
+
+// NewHTLCScript creates a hash time-locked contract paying recipientHash
+// against the preimage of secretHash, or refundHash after lockTime
+func NewHTLCScript(secretHash, recipientHash, refundHash []byte, lockTime int64) (*RedeemScript, error) {
+	if len(secretHash) != sha256.Size {
+		return nil, fmt.Errorf("secret hash must be %d bytes", sha256.Size)
+	}
+	if len(recipientHash) != pubKeyHashLen || len(refundHash) != pubKeyHashLen {
+		return nil, errors.New("recipient and refund must be public key hashes")
+	}
+	if lockTime <= 0 {
+		return nil, errors.New("HTLC needs a lock time")
+	}
+
+	script := &RedeemScript{
+		Type:          ScriptHTLC,
+		SecretHash:    secretHash,
+		RecipientHash: recipientHash,
+		RefundHash:    refundHash,
+		LockTime:      lockTime,
+	}
+
+	return script, nil
+}
+
+// NewRevocableScript creates the output a channel party pays itself in its
+// commitment transaction. The owner waits delay blocks after the commitment
+// confirmed, the counterparty takes the coins at once if the owner revoked
+// the commitment by revealing the preimage of revocationHash.
+func NewRevocableScript(revocationHash, ownerHash, counterpartyHash []byte, delay int) (*RedeemScript, error) {
+	if len(revocationHash) != sha256.Size {
+		return nil, fmt.Errorf("revocation hash must be %d bytes", sha256.Size)
+	}
+	if len(ownerHash) != pubKeyHashLen || len(counterpartyHash) != pubKeyHashLen {
+		return nil, errors.New("owner and counterparty must be public key hashes")
+	}
+	if delay <= 0 || uint32(delay) > sequenceLockTimeMask {
+		return nil, fmt.Errorf("delay must be between 1 and %d blocks", sequenceLockTimeMask)
+	}
+
+	script := &RedeemScript{
+		Type:          ScriptRevocable,
+		SecretHash:    revocationHash,
+		RecipientHash: counterpartyHash,
+		RefundHash:    ownerHash,
+		LockTime:      int64(delay),
+	}
+
+	return script, nil
+}
+
+// Serialize encodes the script in a fixed binary layout so that its hash,
+// and therefore its address, never depends on the Go type definition
+func (rs RedeemScript) Serialize() []byte {
+	var buff bytes.Buffer
+
+	buff.WriteByte(rs.Type)
+	switch rs.Type {
+	case ScriptMultiSig:
+		buff.WriteByte(byte(rs.Required))
+		buff.WriteByte(byte(len(rs.PubKeys)))
+		for _, pubKey := range rs.PubKeys {
+			buff.WriteByte(byte(len(pubKey)))
+			buff.Write(pubKey)
+		}
+	case ScriptHTLC, ScriptRevocable:
+		buff.Write(rs.SecretHash)
+		buff.Write(rs.RecipientHash)
+		buff.Write(rs.RefundHash)
+		binary.Write(&buff, binary.BigEndian, rs.LockTime)
+	case ScriptSchnorr:
+		buff.Write(rs.PubKeys[0])
+	}
+
+	return buff.Bytes()
+}
+
+// DeserializeRedeemScript decodes a serialized redeem script
+func DeserializeRedeemScript(data []byte) (*RedeemScript, error) {
+	r := bytes.NewReader(data)
+
+	scriptType, err := r.ReadByte()
+	if err != nil {
+		return nil, errors.New("empty redeem script")
+	}
+
+	switch scriptType {
+	case ScriptMultiSig:
+		required, err1 := r.ReadByte()
+		count, err2 := r.ReadByte()
+		if err1 != nil || err2 != nil {
+			return nil, errors.New("truncated multisig script")
+		}
+
+		var pubKeys [][]byte
+		for i := 0; i < int(count); i++ {
+			keyLen, err := r.ReadByte()
+			if err != nil {
+				return nil, errors.New("truncated multisig script")
+			}
+			pubKey := make([]byte, keyLen)
+			if n, _ := r.Read(pubKey); n != int(keyLen) {
+				return nil, errors.New("truncated multisig script")
+			}
+			pubKeys = append(pubKeys, pubKey)
+		}
+		if r.Len() != 0 {
+			return nil, errors.New("trailing bytes in multisig script")
+		}
+
+		return NewMultiSigScript(int(required), pubKeys)
+	case ScriptHTLC, ScriptRevocable:
+		if r.Len() != sha256.Size+2*pubKeyHashLen+8 {
+			return nil, errors.New("HTLC script has invalid length")
+		}
+
+		secretHash := make([]byte, sha256.Size)
+		recipientHash := make([]byte, pubKeyHashLen)
+		refundHash := make([]byte, pubKeyHashLen)
+		var lockTime int64
+		r.Read(secretHash)
+		r.Read(recipientHash)
+		r.Read(refundHash)
+		binary.Read(r, binary.BigEndian, &lockTime)
+
+		if scriptType == ScriptRevocable {
+			return NewRevocableScript(secretHash, refundHash, recipientHash, int(lockTime))
+		}
+		return NewHTLCScript(secretHash, recipientHash, refundHash, lockTime)
+	case ScriptSchnorr:
+		pubKey := make([]byte, r.Len())
+		r.Read(pubKey)
+
+		return NewSchnorrScript(pubKey)
+	}
+
+	return nil, fmt.Errorf("unknown redeem script type %d", scriptType)
+}
+
+// Hash returns the hash an output is locked to
+func (rs RedeemScript) Hash() []byte {
+	return HashPubKey(rs.Serialize())
+}
+
+// GetAddress returns the script-hash address of the redeem script
+func (rs RedeemScript) GetAddress() []byte {
+	versionedPayload := append([]byte{scriptVersion}, rs.Hash()...)
+	checksum := checksum(versionedPayload)
+
+	fullPayload := append(versionedPayload, checksum...)
+	address := Base58Encode(fullPayload)
+
+	return address
+}
+
+// KeyIndex returns the position of pubKey in the script or -1
+func (rs RedeemScript) KeyIndex(pubKey []byte) int {
+	for i, key := range rs.PubKeys {
+		if bytes.Equal(key, pubKey) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// AddSignature places a signature made by pubKey into the witness. The
+// witness shape is prepared by whoever builds the spend: multisig has a slot
+// per key, an HTLC redeem is [sig, pubkey, secret] and a refund [sig, pubkey],
+// as are a revoked and a delayed spend of a revocable output. It reports
+// false when the key has no part in the script.
+func (rs RedeemScript) AddSignature(witness [][]byte, pubKey, signature []byte) ([][]byte, bool) {
+	switch rs.Type {
+	case ScriptMultiSig:
+		keyIndex := rs.KeyIndex(pubKey)
+		if keyIndex < 0 {
+			return witness, false
+		}
+		if len(witness) != len(rs.PubKeys) {
+			witness = make([][]byte, len(rs.PubKeys))
+		}
+		witness[keyIndex] = signature
+
+		return witness, true
+	case ScriptHTLC, ScriptRevocable:
+		pubKeyHash := HashPubKey(pubKey)
+		redeem := len(witness) == 3 && bytes.Equal(pubKeyHash, rs.RecipientHash)
+		refund := len(witness) == 2 && bytes.Equal(pubKeyHash, rs.RefundHash)
+		if !redeem && !refund {
+			return witness, false
+		}
+		witness[0] = signature
+		witness[1] = pubKey
+
+		return witness, true
+	}
+
+	return witness, false
+}
+
+// MissingSignatures returns how many signatures the witness still lacks
+func (rs RedeemScript) MissingSignatures(witness [][]byte) int {
+	switch rs.Type {
+	case ScriptMultiSig:
+		signed := 0
+		for _, sig := range witness {
+			if len(sig) > 0 {
+				signed++
+			}
+		}
+		if signed > rs.Required {
+			return 0
+		}
+
+		return rs.Required - signed
+	case ScriptHTLC, ScriptSchnorr, ScriptRevocable:
+		if len(witness) == 0 || len(witness[0]) == 0 {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// Verify checks the witness of input inID of tx against the script, hasher
+// computes the signature hash for the hash type of each signature. For
+// multisig the witness holds one slot per public key, empty slots belong to
+// cosigners that have not signed yet. Schnorr signatures are queued in batch
+// when it is not nil.
+func (rs RedeemScript) Verify(tx *Transaction, inID int, hasher sigHasher, witness [][]byte, batch *SchnorrBatch) bool {
+	switch rs.Type {
+	case ScriptMultiSig:
+		if len(witness) != len(rs.PubKeys) {
+			return false
+		}
+
+		valid := 0
+		for i, sig := range witness {
+			if len(sig) == 0 {
+				continue
+			}
+			if !checkSignature(rs.PubKeys[i], sig, hasher) {
+				return false
+			}
+			valid++
+		}
+
+		return valid >= rs.Required
+	case ScriptHTLC, ScriptRevocable:
+		var pubKeyHash []byte
+
+		switch len(witness) {
+		case 3:
+			secretHash := sha256.Sum256(witness[2])
+			if !bytes.Equal(secretHash[:], rs.SecretHash) {
+				return false
+			}
+			pubKeyHash = rs.RecipientHash
+		case 2:
+			if rs.Type == ScriptRevocable {
+				// The owner's input must carry a relative lock of at least
+				// the delay in blocks, which keeps it out of earlier blocks
+				sequence := tx.Vin[inID].Sequence
+				if sequence&sequenceLockTimeIsSeconds != 0 || int64(sequence&sequenceLockTimeMask) < rs.LockTime {
+					return false
+				}
+			} else {
+				// The refund must carry a lock time at or past the
+				// contract's in the same unit, finality then keeps it out
+				// of earlier blocks
+				if (tx.LockTime < lockTimeThreshold) != (rs.LockTime < lockTimeThreshold) {
+					return false
+				}
+				if tx.LockTime < rs.LockTime {
+					return false
+				}
+			}
+			pubKeyHash = rs.RefundHash
+		default:
+			return false
+		}
+
+		if !bytes.Equal(HashPubKey(witness[1]), pubKeyHash) {
+			return false
+		}
+
+		return checkSignature(witness[1], witness[0], hasher)
+	case ScriptSchnorr:
+		if len(witness) != 1 {
+			return false
+		}
+
+		return checkSchnorrSignature(rs.PubKeys[0], witness[0], hasher, batch)
+	}
+
+	return false
+}
+
+// IsScriptAddress checks whether an address carries the script-hash version
+func IsScriptAddress(address string) bool {
+	payload := Base58Decode([]byte(address))
+
+	return len(payload) > 0 && payload[0] == scriptVersion
+}
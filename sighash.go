@@ -0,0 +1,93 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+)
+
+// Signature hash types, appended as the last byte of every signature
+const (
+	SigHashAll          = byte(0x01)
+	SigHashNone         = byte(0x02)
+	SigHashSingle       = byte(0x03)
+	SigHashAnyoneCanPay = byte(0x80)
+)
+
+// sigHasher returns the signature hash of one input for a hash type
+type sigHasher func(hashType byte) []byte
+
+// isValidHashType checks the base type and the optional ANYONECANPAY flag
+func isValidHashType(hashType byte) bool {
+	base := hashType &^ SigHashAnyoneCanPay
+
+	return base >= SigHashAll && base <= SigHashSingle
+}
+
+// ParseSigHashType parses names like ALL, NONE, SINGLE or ALL|ANYONECANPAY
+func ParseSigHashType(name string) (byte, error) {
+	var hashType byte
+
+	for _, part := range strings.Split(strings.ToUpper(name), "|") {
+		switch strings.TrimSpace(part) {
+		case "ALL":
+			hashType |= SigHashAll
+		case "NONE":
+			hashType |= SigHashNone
+		case "SINGLE":
+			hashType |= SigHashSingle
+		case "ANYONECANPAY":
+			hashType |= SigHashAnyoneCanPay
+		default:
+			return 0, fmt.Errorf("unknown signature hash type %q", part)
+		}
+	}
+	if !isValidHashType(hashType) {
+		return 0, fmt.Errorf("invalid signature hash type %q", name)
+	}
+
+	return hashType, nil
+}
+
+// SignatureHash returns the hash signed for input inID. ALL commits to every
+// output, NONE to none of them and SINGLE only to the output with the same
+// index as the input. ANYONECANPAY drops the other inputs so more can be
+// added after signing. It returns nil when SINGLE has no matching output.
+func (tx *Transaction) SignatureHash(inID int, prevPubKeyHash []byte, hashType byte) []byte {
+	txCopy := tx.TrimmedCopy()
+	txCopy.Vin[inID].PubKey = prevPubKeyHash
+
+	switch hashType &^ SigHashAnyoneCanPay {
+	case SigHashNone:
+		txCopy.Vout = nil
+	case SigHashSingle:
+		if inID >= len(txCopy.Vout) {
+			return nil
+		}
+
+		// Outputs before ours are blanked, later ones are dropped
+		outputs := make([]TXOutput, inID+1)
+		for i := 0; i < inID; i++ {
+			outputs[i] = TXOutput{Value: -1}
+		}
+		outputs[inID] = txCopy.Vout[inID]
+		txCopy.Vout = outputs
+	}
+
+	// Without ALL other signers may still update their inputs
+	if hashType&^SigHashAnyoneCanPay != SigHashAll {
+		for i := range txCopy.Vin {
+			if i != inID {
+				txCopy.Vin[i].Sequence = 0
+			}
+		}
+	}
+
+	if hashType&SigHashAnyoneCanPay != 0 {
+		txCopy.Vin = []TXInput{txCopy.Vin[inID]}
+	}
+
+	hash := sha256.Sum256(append(txCopy.serializedHash(), hashType))
+
+	return hash[:]
+}
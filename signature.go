@@ -0,0 +1,131 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"log"
+	"math/big"
+)
+
+const coordinateLen = 32
+const signatureLen = 2 * coordinateLen
+const compressedPubKeyLen = 1 + coordinateLen
+const legacyPubKeyLen = 2 * coordinateLen
+
+// signHash signs a signature hash with the private key. The signature is
+// r || s, each padded to 32 bytes, with s normalized to the lower half of
+// the curve order so it cannot be flipped into a second valid signature.
+func signHash(privKey ecdsa.PrivateKey, hash []byte) []byte {
+	if privKey.D == nil {
+		log.Panic(ErrWalletLocked)
+	}
+	r, s, err := ecdsa.Sign(rand.Reader, &privKey, hash)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	curveOrder := privKey.Curve.Params().N
+	if s.Cmp(halfOrder(curveOrder)) > 0 {
+		s.Sub(curveOrder, s)
+	}
+
+	signature := make([]byte, signatureLen)
+	r.FillBytes(signature[:coordinateLen])
+	s.FillBytes(signature[coordinateLen:])
+
+	return signature
+}
+
+// verifySignature checks a signature over hash against an encoded public
+// key, non-canonical signatures and keys never verify
+func verifySignature(pubKey, hash, signature []byte) bool {
+	rawPubKey, err := decodePubKey(pubKey)
+	if err != nil || len(signature) != signatureLen {
+		return false
+	}
+
+	r := new(big.Int).SetBytes(signature[:coordinateLen])
+	s := new(big.Int).SetBytes(signature[coordinateLen:])
+
+	curveOrder := rawPubKey.Curve.Params().N
+	if r.Sign() == 0 || r.Cmp(curveOrder) >= 0 {
+		return false
+	}
+	if s.Sign() == 0 || s.Cmp(halfOrder(curveOrder)) > 0 {
+		return false
+	}
+
+	return ecdsa.Verify(rawPubKey, hash, r, s)
+}
+
+// checkSignature verifies a signature carrying its hash type in the last
+// byte against the signature hash computed for that type
+func checkSignature(pubKey, signature []byte, hasher sigHasher) bool {
+	if len(signature) < 2 {
+		return false
+	}
+
+	hashType := signature[len(signature)-1]
+	if !isValidHashType(hashType) {
+		return false
+	}
+	hash := hasher(hashType)
+	if hash == nil {
+		return false
+	}
+
+	return verifySignature(pubKey, hash, signature[:len(signature)-1])
+}
+
+// encodePubKey returns the 33 byte compressed public key stored in wallets
+// and inputs
+func encodePubKey(pubKey *ecdsa.PublicKey) []byte {
+	return elliptic.MarshalCompressed(pubKey.Curve, pubKey.X, pubKey.Y)
+}
+
+// encodeLegacyPubKey returns the fixed-width X || Y encoding. Wallets
+// created before compressed keys hash this form into their addresses.
+func encodeLegacyPubKey(pubKey *ecdsa.PublicKey) []byte {
+	encoded := make([]byte, legacyPubKeyLen)
+	pubKey.X.FillBytes(encoded[:coordinateLen])
+	pubKey.Y.FillBytes(encoded[coordinateLen:])
+
+	return encoded
+}
+
+// pubKeyEncodings returns every accepted encoding of a public key
+func pubKeyEncodings(pubKey *ecdsa.PublicKey) [][]byte {
+	return [][]byte{encodePubKey(pubKey), encodeLegacyPubKey(pubKey)}
+}
+
+// decodePubKey parses a compressed or a legacy fixed-width public key
+func decodePubKey(pubKey []byte) (*ecdsa.PublicKey, error) {
+	curve := elliptic.P256()
+
+	switch len(pubKey) {
+	case compressedPubKeyLen:
+		x, y := elliptic.UnmarshalCompressed(curve, pubKey)
+		if x == nil {
+			return nil, errors.New("invalid compressed public key")
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case legacyPubKeyLen:
+		x := new(big.Int).SetBytes(pubKey[:coordinateLen])
+		y := new(big.Int).SetBytes(pubKey[coordinateLen:])
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("public key is not on the curve")
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+
+	return nil, errors.New("non-canonical public key encoding")
+}
+
+// halfOrder returns half of the curve order, the largest accepted S value
+func halfOrder(curveOrder *big.Int) *big.Int {
+	return new(big.Int).Rsh(curveOrder, 1)
+}
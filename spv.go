@@ -0,0 +1,423 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+const headersFile = "headers.db"
+
+var errLegacyBlock = errors.New("block predates Merkle commitments")
+
+// TransactionProof is a transaction with the proof that the block BlockHash
+// includes it
+type TransactionProof struct {
+	Tx        Transaction
+	BlockHash []byte
+	Proof     MerkleProof
+}
+
+// FullNode is what a light client asks for headers and the transactions of
+// its addresses. Clients that keep their addresses private scan the block
+// filters instead and only fetch the blocks that match.
+type FullNode interface {
+	GetHeaders(fromHeight int) ([]BlockHeader, error)
+	GetTransactions(pubKeyHash []byte) ([]TransactionProof, error)
+	GetBlock(blockHash []byte) (*Block, error)
+	GetFilter(blockHash []byte) (*GCSFilter, error)
+	GetFilterHeader(blockHash []byte) ([]byte, error)
+}
+
+// LocalFullNode serves light clients from a blockchain database on disk,
+// standing in for a remote full node
+type LocalFullNode struct {
+	bc *Blockchain
+}
+
+// NewLocalFullNode opens the blockchain database at path
+func NewLocalFullNode(path string) (*LocalFullNode, error) {
+	bc, err := OpenBlockchain(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LocalFullNode{bc}, nil
+}
+
+// Close closes the blockchain database
+func (n *LocalFullNode) Close() {
+	n.bc.db.Close()
+}
+
+// blocks returns the blocks from fromHeight to the tip, oldest first
+func (n *LocalFullNode) blocks(fromHeight int) []*Block {
+	var blocks []*Block
+
+	bci := n.bc.Iterator()
+	for {
+		block := bci.Next()
+		if block.Height < fromHeight {
+			break
+		}
+		blocks = append([]*Block{block}, blocks...)
+
+		if len(block.PrevBlockHash) == 0 {
+			break
+		}
+	}
+
+	return blocks
+}
+
+// GetHeaders returns the headers from fromHeight to the tip
+func (n *LocalFullNode) GetHeaders(fromHeight int) ([]BlockHeader, error) {
+	var headers []BlockHeader
+
+	for _, block := range n.blocks(fromHeight) {
+		headers = append(headers, block.Header())
+	}
+
+	return headers, nil
+}
+
+// GetBlock returns a block by its hash
+func (n *LocalFullNode) GetBlock(blockHash []byte) (*Block, error) {
+	block, err := n.bc.GetBlock(blockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &block, nil
+}
+
+// GetFilter returns the filter of a block
+func (n *LocalFullNode) GetFilter(blockHash []byte) (*GCSFilter, error) {
+	return n.bc.GetFilter(blockHash)
+}
+
+// GetFilterHeader returns the filter header of a block
+func (n *LocalFullNode) GetFilterHeader(blockHash []byte) ([]byte, error) {
+	return n.bc.GetFilterHeader(blockHash)
+}
+
+// GetTransactions returns the transactions paying pubKeyHash or spending
+// its outputs, with their Merkle proofs
+func (n *LocalFullNode) GetTransactions(pubKeyHash []byte) ([]TransactionProof, error) {
+	var proofs []TransactionProof
+	owned := make(map[string]bool)
+
+	for _, block := range n.blocks(0) {
+		for i, tx := range block.Transactions {
+			relevant := false
+
+			for _, vin := range tx.Vin {
+				if owned[fmt.Sprintf("%x:%d", vin.Txid, vin.Vout)] {
+					relevant = true
+				}
+			}
+			for outIdx, out := range tx.Vout {
+				if out.IsLockedWithKey(pubKeyHash) {
+					owned[fmt.Sprintf("%x:%d", tx.ID, outIdx)] = true
+					relevant = true
+				}
+			}
+
+			if relevant {
+				proofs = append(proofs, TransactionProof{*tx, block.Hash, block.MerkleProof(i)})
+			}
+		}
+	}
+
+	return proofs, nil
+}
+
+// LightClient keeps only the validated header chain and checks
+// transactions from full nodes against it
+type LightClient struct {
+	tip []byte
+	db  *leveldb.DB
+}
+
+// NewLightClient opens or creates the header database
+func NewLightClient() (*LightClient, error) {
+	db, err := leveldb.OpenFile(headersFile, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	tip, err := db.Get([]byte("l"), nil)
+	if err != nil && err != leveldb.ErrNotFound {
+		db.Close()
+		return nil, err
+	}
+
+	return &LightClient{tip, db}, nil
+}
+
+// Close closes the header database
+func (lc *LightClient) Close() {
+	lc.db.Close()
+}
+
+// GetHeader returns a stored header by its block hash
+func (lc *LightClient) GetHeader(hash []byte) (*BlockHeader, error) {
+	data, err := lc.db.Get(hash, nil)
+	if err != nil {
+		return nil, fmt.Errorf("header %x is not known", hash)
+	}
+
+	return DeserializeHeader(data)
+}
+
+// Tip returns the last header of the chain or nil before the first sync
+func (lc *LightClient) Tip() *BlockHeader {
+	if lc.tip == nil {
+		return nil
+	}
+
+	header, err := lc.GetHeader(lc.tip)
+	if err != nil {
+		return nil
+	}
+
+	return header
+}
+
+// forkPoint returns the last stored header the chain of the node shares
+// and the headers the node has after it. Before the first sync there is no
+// stored header and the node's headers start at the genesis.
+func (lc *LightClient) forkPoint(node FullNode) (*BlockHeader, []BlockHeader, error) {
+	header := lc.Tip()
+	if header == nil {
+		headers, err := node.GetHeaders(0)
+		return nil, headers, err
+	}
+
+	for {
+		headers, err := node.GetHeaders(header.Height)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(headers) > 0 && bytes.Equal(headers[0].Hash, header.Hash) {
+			return header, headers[1:], nil
+		}
+		if len(header.PrevBlockHash) == 0 {
+			return nil, nil, errors.New("the chain of the node does not share the genesis header")
+		}
+
+		header, err = lc.GetHeader(header.PrevBlockHash)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+// Sync validates and stores the headers the node has beyond the tip and
+// returns how many were added and how many stored ones were rolled back.
+// When the chain of the node forks from the stored one, the headers past
+// the fork point are replaced if the branch of the node is longer.
+func (lc *LightClient) Sync(node FullNode) (int, int, error) {
+	prev, headers, err := lc.forkPoint(node)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	rolledBack := 0
+	if tip := lc.Tip(); tip != nil {
+		rolledBack = tip.Height - prev.Height
+	}
+	if len(headers) <= rolledBack {
+		if rolledBack == 0 {
+			return 0, 0, nil
+		}
+		return 0, 0, fmt.Errorf("the chain of the node forks at height %d and is not longer than the stored one", prev.Height)
+	}
+
+	batch := new(leveldb.Batch)
+	for i := range headers {
+		header := headers[i]
+		if err := header.Validate(prev); err != nil {
+			return 0, 0, err
+		}
+		batch.Put(header.Hash, header.Serialize())
+		prev = &header
+	}
+	batch.Put([]byte("l"), prev.Hash)
+
+	if err := lc.db.Write(batch, nil); err != nil {
+		return 0, 0, err
+	}
+	lc.tip = prev.Hash
+
+	return len(headers), rolledBack, nil
+}
+
+// VerifyTransaction checks that a transaction is included in a block of
+// the header chain
+func (lc *LightClient) VerifyTransaction(proof TransactionProof) error {
+	header, err := lc.GetHeader(proof.BlockHash)
+	if err != nil {
+		return err
+	}
+	if header.Version < merkleBlockVersion {
+		return errLegacyBlock
+	}
+	if !proof.Proof.Verify(proof.Tx.Serialize(), header.MerkleRoot) {
+		return fmt.Errorf("transaction %x is not in block %x", proof.Tx.ID, header.Hash)
+	}
+
+	return nil
+}
+
+// GetBalance returns the balance of pubKeyHash from the transactions the
+// node reports, counting only those proven to be in the header chain, and
+// how many were skipped because their blocks cannot prove them
+func (lc *LightClient) GetBalance(node FullNode, pubKeyHash []byte) (int, int, error) {
+	proofs, err := node.GetTransactions(pubKeyHash)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	skipped := 0
+	var txs []Transaction
+	for _, proof := range proofs {
+		err := lc.VerifyTransaction(proof)
+		if err == errLegacyBlock {
+			skipped++
+			continue
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+		txs = append(txs, proof.Tx)
+	}
+
+	return spvBalance(txs, pubKeyHash), skipped, nil
+}
+
+// spvBalance sums the coin outputs to pubKeyHash that none of the
+// transactions spends, txs are in chain order
+func spvBalance(txs []Transaction, pubKeyHash []byte) int {
+	unspent := make(map[string]int)
+	for _, tx := range txs {
+		for _, vin := range tx.Vin {
+			delete(unspent, fmt.Sprintf("%x:%d", vin.Txid, vin.Vout))
+		}
+		for outIdx, out := range tx.Vout {
+			if out.IsLockedWithKey(pubKeyHash) && !out.IsAsset() && !out.IsNFT() {
+				unspent[fmt.Sprintf("%x:%d", tx.ID, outIdx)] = out.Value
+			}
+		}
+	}
+
+	balance := 0
+	for _, value := range unspent {
+		balance += value
+	}
+
+	return balance
+}
+
+// headerChain returns the stored headers from the genesis to the tip
+func (lc *LightClient) headerChain() ([]*BlockHeader, error) {
+	var headers []*BlockHeader
+
+	hash := lc.tip
+	for len(hash) > 0 {
+		header, err := lc.GetHeader(hash)
+		if err != nil {
+			return nil, err
+		}
+		headers = append([]*BlockHeader{header}, headers...)
+		hash = header.PrevBlockHash
+	}
+
+	return headers, nil
+}
+
+// ScanFilters finds the transactions paying pubKeyHash or spending its
+// outputs without telling the node which address it is after. It checks
+// every block filter against the chain of filter headers and downloads
+// only the blocks whose filters match, returning their relevant
+// transactions and the number of blocks fetched.
+func (lc *LightClient) ScanFilters(node FullNode, pubKeyHash []byte) ([]Transaction, int, error) {
+	headers, err := lc.headerChain()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var txs []Transaction
+	fetched := 0
+	items := [][]byte{pubKeyHash}
+	prevFilterHeader := make([]byte, sha256.Size)
+	batch := new(leveldb.Batch)
+
+	for _, header := range headers {
+		filter, err := node.GetFilter(header.Hash)
+		if err != nil {
+			return nil, 0, err
+		}
+		filterHeader, err := node.GetFilterHeader(header.Hash)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		// The node must not serve filters that disagree with the filter
+		// headers it committed to, now or in an earlier scan
+		if !bytes.Equal(FilterHeader(filter, prevFilterHeader), filterHeader) {
+			return nil, 0, fmt.Errorf("filter of block %x does not match its filter header", header.Hash)
+		}
+		key := filterDBKey(filterHeaderBucket, header.Hash)
+		if known, err := lc.db.Get(key, nil); err == nil && !bytes.Equal(known, filterHeader) {
+			return nil, 0, fmt.Errorf("filter header of block %x changed since the last scan", header.Hash)
+		}
+		batch.Put(key, filterHeader)
+		prevFilterHeader = filterHeader
+
+		if !filter.MatchAny(items) {
+			continue
+		}
+
+		block, err := node.GetBlock(header.Hash)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !bytes.Equal(block.Hash, header.Hash) || !bytes.Equal(block.HashTransactions(), header.MerkleRoot) {
+			return nil, 0, fmt.Errorf("block %x does not match its header", header.Hash)
+		}
+		if !bytes.Equal(NewBlockFilter(block).Serialize(), filter.Serialize()) {
+			return nil, 0, fmt.Errorf("filter of block %x does not match the block", header.Hash)
+		}
+		fetched++
+
+		for _, tx := range block.Transactions {
+			relevant := false
+			for _, vin := range tx.Vin {
+				for _, item := range items[1:] {
+					if bytes.Equal(item, outpointFilterItem(vin.Txid, vin.Vout)) {
+						relevant = true
+					}
+				}
+			}
+			for outIdx, out := range tx.Vout {
+				if out.IsLockedWithKey(pubKeyHash) {
+					items = append(items, outpointFilterItem(tx.ID, outIdx))
+					relevant = true
+				}
+			}
+			if relevant {
+				txs = append(txs, *tx)
+			}
+		}
+	}
+
+	if err := lc.db.Write(batch, nil); err != nil {
+		return nil, 0, err
+	}
+
+	return txs, fetched, nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// Below lockTimeThreshold Transaction.LockTime is a block height, otherwise
+// it is a unix timestamp
+const lockTimeThreshold = 500000000
+
+// Relative lock encoding of TXInput.Sequence
+const (
+	sequenceLockTimeIsSeconds   = uint32(1 << 22)
+	sequenceLockTimeMask        = uint32(0x0000ffff)
+	sequenceLockTimeGranularity = 9 // time based locks count units of 512 seconds
+
+	// sequenceReplaceable opts the transaction into replace-by-fee, it
+	// carries no relative lock
+	sequenceReplaceable = uint32(1 << 31)
+)
+
+// ErrTimeLocked is returned for transactions that are valid but locked
+var ErrTimeLocked = errors.New("transaction is time-locked")
+
+// RelativeLockBlocks returns the sequence that locks an input until the
+// spent output has the given number of confirmations
+func RelativeLockBlocks(blocks int) uint32 {
+	if blocks < 0 || uint32(blocks) > sequenceLockTimeMask {
+		log.Panicf("ERROR: Relative lock must be between 0 and %d blocks", sequenceLockTimeMask)
+	}
+
+	return uint32(blocks)
+}
+
+// RelativeLockSeconds returns the sequence that locks an input until the
+// given number of seconds passed since the spent output confirmed, rounded
+// up to the 512 second granularity
+func RelativeLockSeconds(seconds int64) uint32 {
+	units := (seconds + (1 << sequenceLockTimeGranularity) - 1) >> sequenceLockTimeGranularity
+	if seconds < 0 || units > int64(sequenceLockTimeMask) {
+		log.Panicf("ERROR: Relative lock must be between 0 and %d seconds", int64(sequenceLockTimeMask)<<sequenceLockTimeGranularity)
+	}
+
+	return sequenceLockTimeIsSeconds | uint32(units)
+}
+
+// IsFinal checks whether the lock time allows the transaction in a block at
+// height whose previous blocks have the median time medianTime
+func (tx Transaction) IsFinal(height int, medianTime int64) bool {
+	if tx.LockTime == 0 {
+		return true
+	}
+	if tx.LockTime < lockTimeThreshold {
+		return tx.LockTime < int64(height)
+	}
+
+	return tx.LockTime < medianTime
+}
+
+// CheckTimeLocks checks the absolute lock time and the relative locks of
+// every input for inclusion in a block at height. Outputs of unconfirmed
+// parents count as confirming in that block.
+func (bc *Blockchain) CheckTimeLocks(tx *Transaction, height int, medianTime int64, parents map[string]*Transaction) error {
+	if !tx.IsFinal(height, medianTime) {
+		return fmt.Errorf("%w: %x is locked until %d", ErrTimeLocked, tx.ID, tx.LockTime)
+	}
+	if tx.IsCoinbase() {
+		return nil
+	}
+
+	UTXOSet := UTXOSet{bc}
+	for _, vin := range tx.Vin {
+		if vin.Sequence&^sequenceReplaceable == 0 {
+			continue
+		}
+		if _, ok := parents[hex.EncodeToString(vin.Txid)]; ok {
+			if vin.Sequence&sequenceLockTimeMask != 0 {
+				return fmt.Errorf("%w: input %x:%d spends an unconfirmed output", ErrTimeLocked, vin.Txid, vin.Vout)
+			}
+			continue
+		}
+
+		outs, err := UTXOSet.GetOutputs(vin.Txid)
+		if err != nil {
+			return err
+		}
+		lock := int64(vin.Sequence & sequenceLockTimeMask)
+
+		if vin.Sequence&sequenceLockTimeIsSeconds != 0 {
+			lock <<= sequenceLockTimeGranularity
+
+			// Measure from the median time before the output confirmed
+			confirmedHeight := outs.Height - 1
+			if confirmedHeight < 0 {
+				confirmedHeight = 0
+			}
+			block, err := bc.GetBlockAtHeight(confirmedHeight)
+			if err != nil {
+				return err
+			}
+			confirmedTime := bc.MedianTimePast(block.Hash)
+
+			if medianTime-confirmedTime < lock {
+				return fmt.Errorf("%w: input %x:%d is locked until %d", ErrTimeLocked, vin.Txid, vin.Vout, confirmedTime+lock)
+			}
+			continue
+		}
+
+		if int64(height-outs.Height) < lock {
+			return fmt.Errorf("%w: input %x:%d is locked until height %d", ErrTimeLocked, vin.Txid, vin.Vout, int64(outs.Height)+lock)
+		}
+	}
+
+	return nil
+}
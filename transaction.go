@@ -3,25 +3,25 @@ package main
 import (
 	"bytes"
 	"crypto/ecdsa"
-	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/sha256"
-	"math/big"
 
 	"encoding/gob"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
 )
 
-const subsidy = 10
-
 // Transaction represents a Bitcoin transaction
 type Transaction struct {
 	ID   []byte
 	Vin  []TXInput
 	Vout []TXOutput
+	// LockTime is the earliest block height, or unix time when it is at
+	// least lockTimeThreshold, at which the transaction may be mined
+	LockTime int64
 }
 
 // IsCoinbase checks whether the transaction is coinbase
@@ -29,6 +29,13 @@ func (tx Transaction) IsCoinbase() bool {
 	return len(tx.Vin) == 1 && len(tx.Vin[0].Txid) == 0 && tx.Vin[0].Vout == -1
 }
 
+// gob numbers types in the order a process first encodes them, so the
+// transaction types are registered up front to keep hashes independent of
+// whatever else the process encoded before signing or verifying
+func init() {
+	Transaction{}.Serialize()
+}
+
 // Serialize returns a serialized Transaction
 func (tx Transaction) Serialize() []byte {
 	var encoded bytes.Buffer
@@ -42,8 +49,20 @@ func (tx Transaction) Serialize() []byte {
 	return encoded.Bytes()
 }
 
-// Hash returns the hash of the Transaction
+// Hash returns the ID of the Transaction, the hash of it without the
+// signatures, public keys, redeem scripts and witnesses signers fill in, so
+// signing does not change it. Coinbase inputs keep their data.
 func (tx *Transaction) Hash() []byte {
+	if tx.IsCoinbase() {
+		return tx.serializedHash()
+	}
+
+	txCopy := tx.TrimmedCopy()
+	return txCopy.serializedHash()
+}
+
+// serializedHash returns the hash of the whole Transaction but its ID
+func (tx *Transaction) serializedHash() []byte {
 	var hash [32]byte
 
 	txCopy := *tx
@@ -54,34 +73,70 @@ func (tx *Transaction) Hash() []byte {
 	return hash[:]
 }
 
-// Sign signs each input of a Transaction
+// Sign signs each input of a Transaction committing to all of it
 func (tx *Transaction) Sign(privKey ecdsa.PrivateKey, prevTXs map[string]Transaction) {
+	tx.SignWithHashType(privKey, prevTXs, SigHashAll)
+}
+
+// SignWithHashType signs every input the key can unlock with the given
+// signature hash type
+func (tx *Transaction) SignWithHashType(privKey ecdsa.PrivateKey, prevTXs map[string]Transaction, hashType byte) {
 	if tx.IsCoinbase() {
 		return
 	}
 
-	for _, vin := range tx.Vin {
-		if prevTXs[hex.EncodeToString(vin.Txid)].ID == nil {
+	prevOutputs := make([]TXOutput, len(tx.Vin))
+	for inID, vin := range tx.Vin {
+		prevTx := prevTXs[hex.EncodeToString(vin.Txid)]
+		if prevTx.ID == nil {
 			log.Panic("ERROR: Previous transaction is not correct")
 		}
+		prevOutputs[inID] = prevTx.Vout[vin.Vout]
 	}
 
-	txCopy := tx.TrimmedCopy()
+	tx.SignPrevOutputs(privKey, prevOutputs, hashType)
+}
 
-	for inID, vin := range txCopy.Vin {
-		prevTx := prevTXs[hex.EncodeToString(vin.Txid)]
-		txCopy.Vin[inID].Signature = nil
-		txCopy.Vin[inID].PubKey = prevTx.Vout[vin.Vout].PubKeyHash
-		txCopy.ID = txCopy.Hash()
-		txCopy.Vin[inID].PubKey = nil
+// SignPrevOutputs signs every input the key can unlock given the outputs
+// the inputs spend, in input order. It needs no access to the blockchain,
+// so offline signers use it on partially signed transactions.
+func (tx *Transaction) SignPrevOutputs(privKey ecdsa.PrivateKey, prevOutputs []TXOutput, hashType byte) {
+	pubKeys := pubKeyEncodings(&privKey.PublicKey)
 
-		r, s, err := ecdsa.Sign(rand.Reader, &privKey, txCopy.ID)
-		if err != nil {
-			log.Panic(err)
+	for inID, vin := range tx.Vin {
+		prevPubKeyHash := prevOutputs[inID].PubKeyHash
+
+		sigHash := tx.SignatureHash(inID, prevPubKeyHash, hashType)
+		if sigHash == nil {
+			log.Panic("ERROR: SINGLE signature hash needs an output for every input")
+		}
+		signature := append(signHash(privKey, sigHash), hashType)
+
+		if len(vin.RedeemScript) > 0 {
+			// Script inputs may need several signers, only fill our own part
+			script, err := DeserializeRedeemScript(vin.RedeemScript)
+			if err != nil {
+				log.Panic(err)
+			}
+			for _, pubKey := range pubKeys {
+				witness, signed := script.AddSignature(vin.Witness, pubKey, signature)
+				if signed {
+					tx.Vin[inID].Witness = witness
+					break
+				}
+			}
+			continue
 		}
-		signature := append(r.Bytes(), s.Bytes()...)
 
-		tx.Vin[inID].Signature = signature
+		// The public key is not covered by the signature hash, unsigned
+		// transactions may leave it for the signer to fill in
+		for _, pubKey := range pubKeys {
+			if bytes.Equal(HashPubKey(pubKey), prevPubKeyHash) {
+				tx.Vin[inID].PubKey = pubKey
+				tx.Vin[inID].Signature = signature
+				break
+			}
+		}
 	}
 }
 
@@ -90,6 +145,9 @@ func (tx Transaction) String() string {
 	var lines []string
 
 	lines = append(lines, fmt.Sprintf("--- Transaction %x:", tx.ID))
+	if tx.LockTime != 0 {
+		lines = append(lines, fmt.Sprintf("     LockTime: %d", tx.LockTime))
+	}
 
 	for i, input := range tx.Vin {
 
@@ -98,71 +156,209 @@ func (tx Transaction) String() string {
 		lines = append(lines, fmt.Sprintf("       Out:       %d", input.Vout))
 		lines = append(lines, fmt.Sprintf("       Signature: %x", input.Signature))
 		lines = append(lines, fmt.Sprintf("       PubKey:    %x", input.PubKey))
+		if input.Sequence != 0 {
+			lines = append(lines, fmt.Sprintf("       Sequence:  %d", input.Sequence))
+		}
+		if len(input.RedeemScript) > 0 {
+			lines = append(lines, fmt.Sprintf("       Redeem:    %x", input.RedeemScript))
+			for j, item := range input.Witness {
+				lines = append(lines, fmt.Sprintf("       Witness %d: %x", j, item))
+			}
+		}
+		if input.Issuance != nil {
+			lines = append(lines, fmt.Sprintf("       Issues:    %d %s as %x", input.Issuance.Supply, input.Issuance.Name, NewAssetID(input.Txid, input.Vout)))
+		}
+		if input.Contract != nil {
+			if len(input.Contract.Code) > 0 {
+				lines = append(lines, fmt.Sprintf("       Deploys:   %x", NewContractID(input.Txid, input.Vout)))
+			} else {
+				lines = append(lines, fmt.Sprintf("       Calls:     %x %v", input.Contract.Contract, input.Contract.Args))
+			}
+			lines = append(lines, fmt.Sprintf("       Gas:       %d at %d", input.Contract.GasLimit, input.Contract.GasPrice))
+		}
+		if input.Governance != nil {
+			if len(input.Governance.Proposal) == 0 {
+				lines = append(lines, fmt.Sprintf("       Proposes:  %s = %d at height %d", input.Governance.Param, input.Governance.Value, input.Governance.Activation))
+			} else {
+				lines = append(lines, fmt.Sprintf("       Votes:     %x approve=%t", input.Governance.Proposal, input.Governance.Approve))
+			}
+		}
+		if input.Name != nil {
+			switch input.Name.Op {
+			case NameClaim:
+				lines = append(lines, fmt.Sprintf("       Claims:    %s", input.Name.Name))
+			case NameRenew:
+				lines = append(lines, fmt.Sprintf("       Renews:    %s", input.Name.Name))
+			default:
+				lines = append(lines, fmt.Sprintf("       Transfers: %s to %s", input.Name.Name, PubKeyHashToAddress(input.Name.To)))
+			}
+		}
 	}
 
 	for i, output := range tx.Vout {
 		lines = append(lines, fmt.Sprintf("     Output %d:", i))
 		lines = append(lines, fmt.Sprintf("       Value:  %d", output.Value))
 		lines = append(lines, fmt.Sprintf("       Script: %x", output.PubKeyHash))
+		if output.IsData() {
+			lines = append(lines, fmt.Sprintf("       Data:   %x", output.Data))
+		}
+		if output.IsAsset() {
+			lines = append(lines, fmt.Sprintf("       Asset:  %d of %x", output.Asset.Amount, output.Asset.ID))
+		}
+		if output.IsNFT() {
+			lines = append(lines, fmt.Sprintf("       NFT:    %x %s", output.NFT.ID, output.NFT.URI))
+		}
 	}
 
 	return strings.Join(lines, "\n")
 }
 
+// CheckID checks that the ID of the transaction is its hash
+func (tx *Transaction) CheckID() error {
+	if !bytes.Equal(tx.ID, tx.Hash()) {
+		return fmt.Errorf("transaction %x does not match its hash %x", tx.ID, tx.Hash())
+	}
+
+	return nil
+}
+
+// maxMoney is the most an output may hold, sums of values beyond it are
+// rejected so they cannot overflow
+const maxMoney = 1 << 53
+
+// addValue adds value to sum, failing for values outside 0 to maxMoney and
+// for sums beyond maxMoney
+func addValue(sum, value int) (int, error) {
+	if value < 0 || value > maxMoney {
+		return 0, fmt.Errorf("value %d is out of range", value)
+	}
+	if sum > maxMoney-value {
+		return 0, fmt.Errorf("sum of values exceeds %d", maxMoney)
+	}
+
+	return sum + value, nil
+}
+
+// CheckOutputs checks the outputs for values out of range and data carriers
+// that break the size limit, more than one per transaction is not allowed
+func (tx *Transaction) CheckOutputs() error {
+	dataOutputs := 0
+
+	for i, out := range tx.Vout {
+		if out.Value < 0 || out.Value > maxMoney {
+			return fmt.Errorf("output %d has value %d out of range", i, out.Value)
+		}
+		if out.IsAsset() {
+			if err := out.checkAsset(); err != nil {
+				return fmt.Errorf("output %d: %s", i, err)
+			}
+		}
+		if out.IsNFT() {
+			if err := out.checkNFT(); err != nil {
+				return fmt.Errorf("output %d: %s", i, err)
+			}
+		}
+		if !out.IsData() {
+			continue
+		}
+
+		dataOutputs++
+		if dataOutputs > 1 {
+			return errors.New("only one data output is allowed per transaction")
+		}
+		if err := out.checkData(); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.OutputValue(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // TrimmedCopy creates a trimmed copy of Transaction to be used in signing
 func (tx *Transaction) TrimmedCopy() Transaction {
 	var inputs []TXInput
 	var outputs []TXOutput
 
 	for _, vin := range tx.Vin {
-		inputs = append(inputs, TXInput{vin.Txid, vin.Vout, nil, nil})
+		inputs = append(inputs, TXInput{Txid: vin.Txid, Vout: vin.Vout, Sequence: vin.Sequence, Issuance: vin.Issuance, Contract: vin.Contract, Governance: vin.Governance, Name: vin.Name})
 	}
 
 	for _, vout := range tx.Vout {
-		outputs = append(outputs, TXOutput{vout.Value, vout.PubKeyHash})
+		outputs = append(outputs, TXOutput{vout.Value, vout.PubKeyHash, vout.Data, vout.Asset, vout.NFT})
 	}
 
-	txCopy := Transaction{tx.ID, inputs, outputs}
+	txCopy := Transaction{tx.ID, inputs, outputs, tx.LockTime}
 
 	return txCopy
 }
 
 // Verify verifies signatures of Transaction inputs
 func (tx *Transaction) Verify(prevTXs map[string]Transaction) bool {
+	return tx.VerifyWithBatch(prevTXs, nil)
+}
+
+// VerifyWithBatch verifies the input signatures, queueing Schnorr
+// signatures in batch when it is not nil so a whole block can be checked
+// at once
+func (tx *Transaction) VerifyWithBatch(prevTXs map[string]Transaction, batch *SchnorrBatch) bool {
 	if tx.IsCoinbase() {
 		return true
 	}
 
-	for _, vin := range tx.Vin {
-		if prevTXs[hex.EncodeToString(vin.Txid)].ID == nil {
+	prevOutputs := make([]TXOutput, len(tx.Vin))
+	for inID, vin := range tx.Vin {
+		prevTx := prevTXs[hex.EncodeToString(vin.Txid)]
+		if prevTx.ID == nil {
 			log.Panic("ERROR: Previous transaction is not correct")
 		}
+		if vin.Vout < 0 || vin.Vout >= len(prevTx.Vout) {
+			return false
+		}
+		prevOutputs[inID] = prevTx.Vout[vin.Vout]
 	}
 
-	txCopy := tx.TrimmedCopy()
-	curve := elliptic.P256()
+	return tx.verifyPrevOutputs(prevOutputs, batch)
+}
+
+// VerifyPrevOutputs verifies the input signatures against the outputs the
+// inputs spend, in input order
+func (tx *Transaction) VerifyPrevOutputs(prevOutputs []TXOutput) bool {
+	return tx.verifyPrevOutputs(prevOutputs, nil)
+}
+
+func (tx *Transaction) verifyPrevOutputs(prevOutputs []TXOutput, batch *SchnorrBatch) bool {
+	if len(prevOutputs) != len(tx.Vin) {
+		return false
+	}
 
 	for inID, vin := range tx.Vin {
-		prevTx := prevTXs[hex.EncodeToString(vin.Txid)]
-		txCopy.Vin[inID].Signature = nil
-		txCopy.Vin[inID].PubKey = prevTx.Vout[vin.Vout].PubKeyHash
-		txCopy.ID = txCopy.Hash()
-		txCopy.Vin[inID].PubKey = nil
-
-		r := big.Int{}
-		s := big.Int{}
-		sigLen := len(vin.Signature)
-		r.SetBytes(vin.Signature[:(sigLen / 2)])
-		s.SetBytes(vin.Signature[(sigLen / 2):])
-
-		x := big.Int{}
-		y := big.Int{}
-		keyLen := len(vin.PubKey)
-		x.SetBytes(vin.PubKey[:(keyLen / 2)])
-		y.SetBytes(vin.PubKey[(keyLen / 2):])
-
-		rawPubKey := ecdsa.PublicKey{curve, &x, &y}
-		if ecdsa.Verify(&rawPubKey, txCopy.ID, &r, &s) == false {
+		prevOut := prevOutputs[inID]
+		if prevOut.IsData() {
+			return false
+		}
+
+		hasher := func(hashType byte) []byte {
+			return tx.SignatureHash(inID, prevOut.PubKeyHash, hashType)
+		}
+
+		if len(vin.RedeemScript) > 0 {
+			script, err := DeserializeRedeemScript(vin.RedeemScript)
+			if err != nil || !bytes.Equal(script.Hash(), prevOut.PubKeyHash) {
+				return false
+			}
+			if !script.Verify(tx, inID, hasher, vin.Witness, batch) {
+				return false
+			}
+			continue
+		}
+
+		if !vin.UsesKey(prevOut.PubKeyHash) {
+			return false
+		}
+		if !checkSignature(vin.PubKey, vin.Signature, hasher) {
 			return false
 		}
 	}
@@ -170,8 +366,9 @@ func (tx *Transaction) Verify(prevTXs map[string]Transaction) bool {
 	return true
 }
 
-// NewCoinbaseTX creates a new coinbase transaction
-func NewCoinbaseTX(to, data string) *Transaction {
+// NewCoinbaseTX creates a new coinbase transaction paying the active subsidy
+// and the fees of the other transactions of its block
+func NewCoinbaseTX(to, data string, fees int) *Transaction {
 	if data == "" {
 		randData := make([]byte, 20)
 		_, err := rand.Read(randData)
@@ -182,53 +379,38 @@ func NewCoinbaseTX(to, data string) *Transaction {
 		data = fmt.Sprintf("%x", randData)
 	}
 
-	txin := TXInput{[]byte{}, -1, nil, []byte(data)}
-	txout := NewTXOutput(subsidy, to)
-	tx := Transaction{nil, []TXInput{txin}, []TXOutput{*txout}}
+	txin := TXInput{Txid: []byte{}, Vout: -1, PubKey: []byte(data)}
+	txout := NewTXOutput(chainParams().Subsidy+fees, to)
+	tx := Transaction{nil, []TXInput{txin}, []TXOutput{*txout}, 0}
 	tx.ID = tx.Hash()
 
 	return &tx
 }
 
-// NewUTXOTransaction creates a new transaction
-func NewUTXOTransaction(from, to string, amount int, UTXOSet *UTXOSet) *Transaction {
-	var inputs []TXInput
-	var outputs []TXOutput
+// NewUTXOTransaction creates a new transaction, lockTime and sequence are
+// applied before signing so the signatures commit to them
+func NewUTXOTransaction(from, to string, amount int, lockTime int64, sequence uint32, UTXOSet *UTXOSet) *Transaction {
+	return NewBatchTransaction(from, []Payment{{to, amount}}, lockTime, sequence, DefaultCoinSelection, UTXOSet)
+}
 
-	wallets, err := NewWallets()
-	if err != nil {
-		log.Panic(err)
-	}
-	wallet := wallets.GetWallet(from)
-	pubKeyHash := HashPubKey(wallet.PublicKey)
-	acc, validOutputs := UTXOSet.FindSpendableOutputs(pubKeyHash, amount)
+// DeserializeTransaction deserializes a transaction, refusing data beyond
+// the transaction size limit before decoding it and transactions breaking
+// the input, output or signature check limits after
+func DeserializeTransaction(data []byte) Transaction {
+	var transaction Transaction
 
-	if acc < amount {
-		log.Panic("ERROR: Not enough funds")
+	if len(data) > chainParams().MaxTxSize {
+		log.Panicf("ERROR: Transaction has %d bytes, at most %d are allowed", len(data), chainParams().MaxTxSize)
 	}
 
-	// Build a list of inputs
-	for txid, outs := range validOutputs {
-		txID, err := hex.DecodeString(txid)
-		if err != nil {
-			log.Panic(err)
-		}
-
-		for _, out := range outs {
-			input := TXInput{txID, out, nil, wallet.PublicKey}
-			inputs = append(inputs, input)
-		}
+	decoder := gob.NewDecoder(bytes.NewReader(data))
+	err := decoder.Decode(&transaction)
+	if err != nil {
+		log.Panic(err)
 	}
-
-	// Build a list of outputs
-	outputs = append(outputs, *NewTXOutput(amount, to))
-	if acc > amount {
-		outputs = append(outputs, *NewTXOutput(acc-amount, from)) // a change
+	if err := transaction.CheckLimits(); err != nil {
+		log.Panicf("ERROR: %s", err)
 	}
 
-	tx := Transaction{nil, inputs, outputs}
-	tx.ID = tx.Hash()
-	UTXOSet.Blockchain.SignTransaction(&tx, wallet.PrivateKey)
-
-	return &tx
-}
\ No newline at end of file
+	return transaction
+}
@@ -8,6 +8,22 @@ type TXInput struct {
 	Vout      int
 	Signature []byte
 	PubKey    []byte
+	// Sequence encodes a relative lock against the confirmation of the
+	// spent output, zero means the input is not locked
+	Sequence uint32
+	// RedeemScript and Witness are only set when spending a script-hash output
+	RedeemScript []byte
+	Witness      [][]byte
+	// Issuance creates a new asset whose ID is derived from the outpoint
+	// this input spends
+	Issuance *AssetIssuance
+	// Contract deploys or calls a contract, only on the first input
+	Contract *ContractCall
+	// Governance proposes a parameter change or votes on a proposal, only
+	// on the first input
+	Governance *GovernanceAction
+	// Name claims, renews or transfers a name, only on the first input
+	Name *NameAction
 }
 
 // UsesKey checks whether the address initiated the transaction
@@ -3,13 +3,27 @@ package main
 import (
 	"bytes"
 	"encoding/gob"
+	"errors"
+	"fmt"
 	"log"
 )
 
-// TXOutput represents a transaction output
+const maxDataCarrierSize = 80
+
+// TXOutput represents a transaction output. PubKeyHash holds either the hash
+// of a public key or the hash of a redeem script, depending on the address
+// version the output was locked to.
 type TXOutput struct {
 	Value      int
 	PubKeyHash []byte
+	// Data makes the output a provably unspendable data carrier, such
+	// outputs never enter the UTXO set
+	Data []byte
+	// Asset is the amount of an issued asset the output holds besides
+	// Value
+	Asset *AssetAmount
+	// NFT is the unique token the output holds
+	NFT *NFT
 }
 
 // Lock signs the output
@@ -21,20 +35,79 @@ func (out *TXOutput) Lock(address []byte) {
 
 // IsLockedWithKey checks if the output can be used by the owner of the pubkey
 func (out *TXOutput) IsLockedWithKey(pubKeyHash []byte) bool {
-	return bytes.Compare(out.PubKeyHash, pubKeyHash) == 0
+	return !out.IsData() && bytes.Compare(out.PubKeyHash, pubKeyHash) == 0
+}
+
+// IsData checks whether the output only carries data
+func (out *TXOutput) IsData() bool {
+	return len(out.Data) > 0
 }
 
 // NewTXOutput create a new TXOutput
 func NewTXOutput(value int, address string) *TXOutput {
-	txo := &TXOutput{value, nil}
+	txo := &TXOutput{value, nil, nil, nil, nil}
 	txo.Lock([]byte(address))
 
 	return txo
 }
 
-// TXOutputs collects TXOutput
+// NewDataOutput creates an unspendable output carrying data
+func NewDataOutput(data []byte) (*TXOutput, error) {
+	txo := &TXOutput{0, nil, data, nil, nil}
+	if err := txo.checkData(); err != nil {
+		return nil, err
+	}
+
+	return txo, nil
+}
+
+// checkData enforces the data carrier rules on an output
+func (out *TXOutput) checkData() error {
+	if len(out.Data) > maxDataCarrierSize {
+		return fmt.Errorf("data output carries %d bytes, the limit is %d", len(out.Data), maxDataCarrierSize)
+	}
+	if out.Value != 0 || len(out.PubKeyHash) != 0 {
+		return errors.New("data output must have no value and no owner")
+	}
+
+	return nil
+}
+
+// TXOutputs collects the unspent TXOutput of a transaction
 type TXOutputs struct {
 	Outputs []TXOutput
+	// Indexes holds the position of each output in its transaction,
+	// entries written before it existed fall back to the slice position
+	Indexes []int
+	// Height of the block that confirmed the transaction
+	Height int
+	// Coinbase marks the outputs of a coinbase transaction
+	Coinbase bool
+}
+
+// IsMature checks whether the outputs can be spent in a block at height.
+// The genesis coinbase can never be reorganized away and is always mature.
+func (outs TXOutputs) IsMature(height int) bool {
+	if !outs.Coinbase || outs.Height == 0 {
+		return true
+	}
+
+	return height-outs.Height >= chainParams().CoinbaseMaturity
+}
+
+// Index returns the transaction output index of the i-th collected output
+func (outs TXOutputs) Index(i int) int {
+	if i < len(outs.Indexes) {
+		return outs.Indexes[i]
+	}
+
+	return i
+}
+
+// Add appends an output together with its index in the transaction
+func (outs *TXOutputs) Add(outIdx int, out TXOutput) {
+	outs.Outputs = append(outs.Outputs, out)
+	outs.Indexes = append(outs.Indexes, outIdx)
 }
 
 // Serialize serializes TXOutputs
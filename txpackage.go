@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/hex"
+	"log"
+	"sort"
+)
+
+// maxMempoolSize bounds the serialized size of all pending transactions,
+// the packages paying the least are evicted beyond it
+const maxMempoolSize = 4 << 20
+
+// poolEntry is a pending transaction with the figures it is ranked by
+type poolEntry struct {
+	tx      *Transaction
+	fee     int
+	size    int
+	sigOps  int
+	parents []string
+}
+
+// txPackage is a set of pending transactions evaluated together
+type txPackage map[string]bool
+
+// entries returns every pending transaction by hex ID along with its fee,
+// size and the pending transactions it spends from
+func (m Mempool) entries() map[string]*poolEntry {
+	pool := m.pool()
+	entries := make(map[string]*poolEntry)
+
+	for id, tx := range pool {
+		entry := &poolEntry{tx: tx, fee: m.fee(tx, pool), size: tx.Size(), sigOps: tx.SigOps()}
+		seen := make(map[string]bool)
+
+		for _, vin := range tx.Vin {
+			parentID := hex.EncodeToString(vin.Txid)
+			if _, ok := pool[parentID]; ok && !seen[parentID] {
+				entry.parents = append(entry.parents, parentID)
+				seen[parentID] = true
+			}
+		}
+
+		entries[id] = entry
+	}
+
+	return entries
+}
+
+// fee returns what a transaction pays, its inputs may spend outputs of the
+// pending transactions in pool
+func (m Mempool) fee(tx *Transaction, pool map[string]*Transaction) int {
+	fee, err := m.Blockchain.TransactionFee(tx, pool)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return fee
+}
+
+// Fee returns what a pending transaction pays
+func (m Mempool) Fee(tx *Transaction) int {
+	return m.fee(tx, m.pool())
+}
+
+// ancestors returns a pending transaction together with all the pending
+// transactions it depends on
+func ancestors(id string, entries map[string]*poolEntry) txPackage {
+	pkg := txPackage{}
+
+	var visit func(string)
+	visit = func(id string) {
+		if pkg[id] {
+			return
+		}
+		pkg[id] = true
+		for _, parent := range entries[id].parents {
+			visit(parent)
+		}
+	}
+	visit(id)
+
+	return pkg
+}
+
+// descendants returns a pending transaction together with all the pending
+// transactions that depend on it
+func descendants(id string, entries map[string]*poolEntry) txPackage {
+	children := make(map[string][]string)
+	for childID, entry := range entries {
+		for _, parent := range entry.parents {
+			children[parent] = append(children[parent], childID)
+		}
+	}
+
+	pkg := txPackage{}
+	var visit func(string)
+	visit = func(id string) {
+		if pkg[id] {
+			return
+		}
+		pkg[id] = true
+		for _, child := range children[id] {
+			visit(child)
+		}
+	}
+	visit(id)
+
+	return pkg
+}
+
+// feeAndSize sums the fees and sizes of a package
+func (pkg txPackage) feeAndSize(entries map[string]*poolEntry) (int, int) {
+	fee, size := 0, 0
+	for id := range pkg {
+		fee += entries[id].fee
+		size += entries[id].size
+	}
+
+	return fee, size
+}
+
+// sigOps sums the signature checks of a package
+func (pkg txPackage) sigOps(entries map[string]*poolEntry) int {
+	sigOps := 0
+	for id := range pkg {
+		sigOps += entries[id].sigOps
+	}
+
+	return sigOps
+}
+
+// sortedIDs returns the IDs of the package with parents before children
+func (pkg txPackage) sortedIDs(entries map[string]*poolEntry) []string {
+	var ids []string
+	for id := range pkg {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var ordered []string
+	added := make(map[string]bool)
+	var visit func(string)
+	visit = func(id string) {
+		if added[id] {
+			return
+		}
+		added[id] = true
+		for _, parent := range entries[id].parents {
+			if pkg[parent] {
+				visit(parent)
+			}
+		}
+		ordered = append(ordered, id)
+	}
+	for _, id := range ids {
+		visit(id)
+	}
+
+	return ordered
+}
+
+// BlockTemplate picks the pending transactions for the next block. Every
+// transaction is ranked by the fee rate of its ancestor package, itself and
+// the ancestors not yet picked, so a child paying a high fee pulls its low
+// fee parents in ahead of others. Packages are added with parents first
+// while they fit into maxSize bytes and maxSigOps signature checks, zero
+// means no limit.
+func (m Mempool) BlockTemplate(maxSize, maxSigOps int) []*Transaction {
+	entries := m.entries()
+	var ids []string
+	for id := range entries {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var template []*Transaction
+	picked := make(map[string]bool)
+	skipped := make(map[string]bool)
+	size, sigOps := 0, 0
+
+	for {
+		var best txPackage
+		bestFee, bestSize := 0, 0
+
+		for _, id := range ids {
+			if picked[id] || skipped[id] {
+				continue
+			}
+
+			pkg := txPackage{}
+			for ancestor := range ancestors(id, entries) {
+				if !picked[ancestor] {
+					pkg[ancestor] = true
+				}
+			}
+			fee, pkgSize := pkg.feeAndSize(entries)
+			if best == nil || feeRateHigher(fee, pkgSize, bestFee, bestSize) {
+				best, bestFee, bestSize = pkg, fee, pkgSize
+			}
+		}
+		if best == nil {
+			break
+		}
+
+		bestSigOps := best.sigOps(entries)
+		if (maxSize > 0 && size+bestSize > maxSize) || (maxSigOps > 0 && sigOps+bestSigOps > maxSigOps) {
+			for id := range best {
+				skipped[id] = true
+			}
+			continue
+		}
+
+		for _, id := range best.sortedIDs(entries) {
+			template = append(template, entries[id].tx)
+			picked[id] = true
+		}
+		size += bestSize
+		sigOps += bestSigOps
+	}
+
+	return template
+}
+
+// evictionScore returns the fee and size a pending transaction is judged
+// by when the pool is full: its own fee rate or that of it together with
+// its descendants, whichever is higher, so parents with a well paying child
+// are kept
+func evictionScore(id string, entries map[string]*poolEntry) (int, int) {
+	fee, size := descendants(id, entries).feeAndSize(entries)
+	if feeRateHigher(entries[id].fee, entries[id].size, fee, size) {
+		return entries[id].fee, entries[id].size
+	}
+
+	return fee, size
+}
+
+// trim evicts the transactions with the lowest eviction score, along with
+// their descendants, until the pool fits into maxMempoolSize. It returns
+// the IDs of the evicted transactions.
+func (m Mempool) trim() txPackage {
+	entries := m.entries()
+	evicted := txPackage{}
+
+	total := 0
+	for _, entry := range entries {
+		total += entry.size
+	}
+
+	for total > maxMempoolSize {
+		worst := ""
+		worstFee, worstSize := 0, 0
+		for id := range entries {
+			if evicted[id] {
+				continue
+			}
+			fee, size := evictionScore(id, entries)
+			if worst == "" || feeRateHigher(worstFee, worstSize, fee, size) {
+				worst, worstFee, worstSize = id, fee, size
+			}
+		}
+
+		for id := range descendants(worst, entries) {
+			if !evicted[id] {
+				evicted[id] = true
+				total -= entries[id].size
+			}
+		}
+	}
+
+	m.remove(evicted)
+	return evicted
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"encoding/hex"
+	"fmt"
 	"log"
+	"strconv"
 
 	"github.com/syndtr/goleveldb/leveldb"
 )
@@ -23,7 +25,25 @@ func getKey(txID string) []byte {
 func (u UTXOSet) FindSpendableOutputs(pubkeyHash []byte, amount int) (int, map[string][]int) {
 	unspentOutputs := make(map[string][]int)
 	accumulated := 0
+
+	for _, coin := range u.FindCoins(pubkeyHash) {
+		if accumulated >= amount {
+			break
+		}
+		accumulated += coin.Value
+		unspentOutputs[coin.TxID] = append(unspentOutputs[coin.TxID], coin.Index)
+	}
+
+	return accumulated, unspentOutputs
+}
+
+// FindCoins returns the mature outputs locked to a public key hash that no
+// pending transaction spends, in database order
+func (u UTXOSet) FindCoins(pubkeyHash []byte) []Coin {
+	var coins []Coin
 	db := u.Blockchain.db
+	pending := Mempool{u.Blockchain}.SpentOutputs()
+	height := u.Blockchain.GetBestHeight() + 1
 
 	iter := db.NewIterator(nil, nil)
 	for iter.Next() {
@@ -32,11 +52,18 @@ func (u UTXOSet) FindSpendableOutputs(pubkeyHash []byte, amount int) (int, map[s
 		if len(key) > len(utxoBucket) && string(key[:len(utxoBucket)]) == utxoBucket {
 			txID := string(key[len(utxoBucket)+1:]) // Extract the transaction ID
 			outs := DeserializeOutputs(iter.Value())
+			if !outs.IsMature(height) {
+				continue
+			}
 
-			for outIdx, out := range outs.Outputs {
-				if out.IsLockedWithKey(pubkeyHash) && accumulated < amount {
-					accumulated += out.Value
-					unspentOutputs[txID] = append(unspentOutputs[txID], outIdx)
+			for i, out := range outs.Outputs {
+				// Skip outputs already spent by pending transactions
+				if _, ok := pending[txID+":"+strconv.Itoa(outs.Index(i))]; ok {
+					continue
+				}
+				// Asset and NFT outputs are only spent by their transfers
+				if out.IsLockedWithKey(pubkeyHash) && !out.IsAsset() && !out.IsNFT() {
+					coins = append(coins, Coin{txID, outs.Index(i), out.Value})
 				}
 			}
 		}
@@ -46,7 +73,7 @@ func (u UTXOSet) FindSpendableOutputs(pubkeyHash []byte, amount int) (int, map[s
 		log.Panic(err)
 	}
 
-	return accumulated, unspentOutputs
+	return coins
 }
 
 // FindUTXO finds UTXO for a public key hash
@@ -76,6 +103,69 @@ func (u UTXOSet) FindUTXO(pubKeyHash []byte) []TXOutput {
 	return UTXOs
 }
 
+// GetBalance returns the spendable and the immature coinbase balance of a
+// public key hash
+func (u UTXOSet) GetBalance(pubKeyHash []byte) (int, int) {
+	balance, immature := 0, 0
+	db := u.Blockchain.db
+	height := u.Blockchain.GetBestHeight() + 1
+
+	iter := db.NewIterator(nil, nil)
+	for iter.Next() {
+		key := iter.Key()
+		// Check if the key has the chainstate prefix
+		if len(key) > len(utxoBucket) && string(key[:len(utxoBucket)]) == utxoBucket {
+			outs := DeserializeOutputs(iter.Value())
+
+			for _, out := range outs.Outputs {
+				if !out.IsLockedWithKey(pubKeyHash) {
+					continue
+				}
+				if outs.IsMature(height) {
+					balance += out.Value
+				} else {
+					immature += out.Value
+				}
+			}
+		}
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		log.Panic(err)
+	}
+
+	return balance, immature
+}
+
+// GetOutputs returns the unspent outputs of a transaction
+func (u UTXOSet) GetOutputs(txID []byte) (TXOutputs, error) {
+	outsBytes, err := u.Blockchain.db.Get(getKey(hex.EncodeToString(txID)), nil)
+	if err == leveldb.ErrNotFound {
+		return TXOutputs{}, fmt.Errorf("no unspent outputs for transaction %x", txID)
+	}
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return DeserializeOutputs(outsBytes), nil
+}
+
+// FindOutput returns an unspent output by its transaction ID and index
+func (u UTXOSet) FindOutput(txID []byte, outIdx int) (*TXOutput, error) {
+	outs, err := u.GetOutputs(txID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, out := range outs.Outputs {
+		if outs.Index(i) == outIdx {
+			return &out, nil
+		}
+	}
+
+	return nil, fmt.Errorf("output %x:%d is spent or does not exist", txID, outIdx)
+}
+
 // CountTransactions returns the number of transactions in the UTXO set
 func (u UTXOSet) CountTransactions() int {
 	db := u.Blockchain.db
@@ -101,7 +191,8 @@ func (u UTXOSet) CountTransactions() int {
 func (u UTXOSet) Reindex() {
 	db := u.Blockchain.db
 
-	// Clear the existing UTXO set by deleting all keys with the chainstate prefix
+	// Clear the existing UTXO set and the NFT index derived from it by
+	// deleting all keys with their prefixes
 	batch := new(leveldb.Batch)
 	iter := db.NewIterator(nil, nil)
 	for iter.Next() {
@@ -109,6 +200,9 @@ func (u UTXOSet) Reindex() {
 		if len(key) > len(utxoBucket) && string(key[:len(utxoBucket)]) == utxoBucket {
 			batch.Delete(key)
 		}
+		if len(key) > len(nftBucket) && string(key[:len(nftBucket)+1]) == nftBucket+"_" {
+			batch.Delete(key)
+		}
 	}
 	iter.Release()
 	if err := iter.Error(); err != nil {
@@ -120,6 +214,12 @@ func (u UTXOSet) Reindex() {
 	for txID, outs := range UTXO {
 		key := getKey(txID)
 		batch.Put(key, outs.Serialize())
+
+		id, err := hex.DecodeString(txID)
+		if err != nil {
+			log.Panic(err)
+		}
+		indexNFTs(batch, id, outs)
 	}
 
 	if err := db.Write(batch, nil); err != nil {
@@ -132,39 +232,58 @@ func (u UTXOSet) Reindex() {
 func (u UTXOSet) Update(block *Block) {
 	db := u.Blockchain.db
 
-	batch := new(leveldb.Batch)
+	// Entries changed by earlier transactions of the block are read back
+	// from here, a transaction may spend several outputs of the same
+	// transaction or outputs created earlier in the block
+	updated := make(map[string]TXOutputs)
+	getOutputs := func(txID string) TXOutputs {
+		if outs, ok := updated[txID]; ok {
+			return outs
+		}
+		outsBytes, err := db.Get(getKey(txID), nil)
+		if err != nil && err != leveldb.ErrNotFound {
+			log.Panic(err)
+		}
+
+		return DeserializeOutputs(outsBytes)
+	}
+
 	for _, tx := range block.Transactions {
 		if !tx.IsCoinbase() {
 			for _, vin := range tx.Vin {
-				key := getKey(hex.EncodeToString(vin.Txid))
-				outsBytes, err := db.Get(key, nil)
-				if err != nil && err != leveldb.ErrNotFound {
-					log.Panic(err)
-				}
-
-				updatedOuts := TXOutputs{}
-				outs := DeserializeOutputs(outsBytes)
-				for outIdx, out := range outs.Outputs {
-					if outIdx != vin.Vout {
-						updatedOuts.Outputs = append(updatedOuts.Outputs, out)
+				txID := hex.EncodeToString(vin.Txid)
+				outs := getOutputs(txID)
+				updatedOuts := TXOutputs{Height: outs.Height, Coinbase: outs.Coinbase}
+				for i, out := range outs.Outputs {
+					if outs.Index(i) != vin.Vout {
+						updatedOuts.Add(outs.Index(i), out)
 					}
 				}
 
-				if len(updatedOuts.Outputs) == 0 {
-					batch.Delete(key)
-				} else {
-					batch.Put(key, updatedOuts.Serialize())
-				}
+				updated[txID] = updatedOuts
 			}
 		}
 
-		newOutputs := TXOutputs{}
-		for _, out := range tx.Vout {
-			newOutputs.Outputs = append(newOutputs.Outputs, out)
+		newOutputs := TXOutputs{Height: block.Height, Coinbase: tx.IsCoinbase()}
+		for outIdx, out := range tx.Vout {
+			if out.IsData() {
+				continue
+			}
+			newOutputs.Add(outIdx, out)
 		}
+		updated[hex.EncodeToString(tx.ID)] = newOutputs
+	}
 
-		key := getKey(hex.EncodeToString(tx.ID))
-		batch.Put(key, newOutputs.Serialize())
+	batch := new(leveldb.Batch)
+	for _, tx := range block.Transactions {
+		indexNFTs(batch, tx.ID, updated[hex.EncodeToString(tx.ID)])
+	}
+	for txID, outs := range updated {
+		if len(outs.Outputs) == 0 {
+			batch.Delete(getKey(txID))
+		} else {
+			batch.Put(getKey(txID), outs.Serialize())
+		}
 	}
 
 	if err := db.Write(batch, nil); err != nil {
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Contract opcodes. The VM is a stack machine over int64 values with a
+// key-value storage per contract, PUSH is followed by an 8 byte big-endian
+// immediate and jumps must land on an instruction.
+const (
+	OpStop   = byte(0x00)
+	OpPush   = byte(0x01)
+	OpPop    = byte(0x02)
+	OpDup    = byte(0x03)
+	OpSwap   = byte(0x04)
+	OpAdd    = byte(0x10)
+	OpSub    = byte(0x11)
+	OpMul    = byte(0x12)
+	OpDiv    = byte(0x13)
+	OpMod    = byte(0x14)
+	OpLt     = byte(0x15)
+	OpGt     = byte(0x16)
+	OpEq     = byte(0x17)
+	OpNot    = byte(0x18)
+	OpJump   = byte(0x20)
+	OpJumpI  = byte(0x21)
+	OpSLoad  = byte(0x30)
+	OpSStore = byte(0x31)
+	OpArg    = byte(0x40)
+	OpArgc   = byte(0x41)
+	OpReturn = byte(0x50)
+	OpRevert = byte(0x51)
+)
+
+const maxStackDepth = 1024
+
+// opcodes maps the assembler mnemonics to opcodes and their gas cost
+var opcodes = map[string]struct {
+	op  byte
+	gas int
+}{
+	"STOP":   {OpStop, 0},
+	"PUSH":   {OpPush, 1},
+	"POP":    {OpPop, 1},
+	"DUP":    {OpDup, 1},
+	"SWAP":   {OpSwap, 1},
+	"ADD":    {OpAdd, 1},
+	"SUB":    {OpSub, 1},
+	"MUL":    {OpMul, 3},
+	"DIV":    {OpDiv, 3},
+	"MOD":    {OpMod, 3},
+	"LT":     {OpLt, 1},
+	"GT":     {OpGt, 1},
+	"EQ":     {OpEq, 1},
+	"NOT":    {OpNot, 1},
+	"JUMP":   {OpJump, 2},
+	"JUMPI":  {OpJumpI, 2},
+	"SLOAD":  {OpSLoad, 20},
+	"SSTORE": {OpSStore, 100},
+	"ARG":    {OpArg, 1},
+	"ARGC":   {OpArgc, 1},
+	"RETURN": {OpReturn, 0},
+	"REVERT": {OpRevert, 0},
+}
+
+var opcodeGas = make(map[byte]int)
+
+func init() {
+	for _, opcode := range opcodes {
+		opcodeGas[opcode.op] = opcode.gas
+	}
+}
+
+// Errors ending a contract execution, all of them revert its writes
+var (
+	ErrOutOfGas       = errors.New("out of gas")
+	ErrContractRevert = errors.New("execution reverted")
+)
+
+// ContractStorage is the persistent storage of one contract, keys that
+// were never written load as zero
+type ContractStorage interface {
+	Load(key int64) int64
+	Store(key, value int64)
+}
+
+// ExecutionResult is the outcome of running a contract
+type ExecutionResult struct {
+	Success bool
+	GasUsed int
+	Result  int64
+	Error   string
+}
+
+// instructionStarts returns the offsets of the instructions of code, or an
+// error for unknown opcodes and truncated immediates
+func instructionStarts(code []byte) (map[int]bool, error) {
+	starts := make(map[int]bool)
+
+	for pc := 0; pc < len(code); pc++ {
+		if _, ok := opcodeGas[code[pc]]; !ok {
+			return nil, fmt.Errorf("unknown opcode 0x%02x at %d", code[pc], pc)
+		}
+		starts[pc] = true
+		if code[pc] == OpPush {
+			if pc+8 >= len(code) {
+				return nil, fmt.Errorf("truncated PUSH at %d", pc)
+			}
+			pc += 8
+		}
+	}
+
+	return starts, nil
+}
+
+// RunContract executes code with the call arguments. Storage writes are
+// kept aside and only reach storage when the execution succeeds, so a
+// revert, an error or running out of gas leaves no trace but the gas.
+func RunContract(code []byte, args []int64, gasLimit int, storage ContractStorage) ExecutionResult {
+	writes := make(map[int64]int64)
+	result, gasUsed, err := execute(code, args, gasLimit, storage, writes)
+	if err != nil {
+		return ExecutionResult{false, gasUsed, 0, err.Error()}
+	}
+
+	for key, value := range writes {
+		storage.Store(key, value)
+	}
+
+	return ExecutionResult{true, gasUsed, result, ""}
+}
+
+func execute(code []byte, args []int64, gasLimit int, storage ContractStorage, writes map[int64]int64) (int64, int, error) {
+	starts, err := instructionStarts(code)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var stack []int64
+	gasUsed := 0
+
+	pop := func() (int64, error) {
+		if len(stack) == 0 {
+			return 0, errors.New("stack underflow")
+		}
+		value := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		return value, nil
+	}
+	pop2 := func() (int64, int64, error) {
+		b, err := pop()
+		if err != nil {
+			return 0, 0, err
+		}
+		a, err := pop()
+		return a, b, err
+	}
+	boolean := func(b bool) int64 {
+		if b {
+			return 1
+		}
+		return 0
+	}
+
+	for pc := 0; pc < len(code); {
+		op := code[pc]
+		gasUsed += opcodeGas[op]
+		if gasUsed > gasLimit {
+			return 0, gasLimit, ErrOutOfGas
+		}
+		next := pc + 1
+
+		var push []int64
+		switch op {
+		case OpStop:
+			return 0, gasUsed, nil
+		case OpPush:
+			push = []int64{int64(binary.BigEndian.Uint64(code[pc+1 : pc+9]))}
+			next = pc + 9
+		case OpPop:
+			_, err = pop()
+		case OpDup:
+			var a int64
+			a, err = pop()
+			push = []int64{a, a}
+		case OpSwap:
+			var a, b int64
+			a, b, err = pop2()
+			push = []int64{b, a}
+		case OpAdd, OpSub, OpMul, OpDiv, OpMod, OpLt, OpGt, OpEq:
+			var a, b int64
+			a, b, err = pop2()
+			if err != nil {
+				break
+			}
+			switch op {
+			case OpAdd:
+				push = []int64{a + b}
+			case OpSub:
+				push = []int64{a - b}
+			case OpMul:
+				push = []int64{a * b}
+			case OpDiv, OpMod:
+				if b == 0 {
+					err = errors.New("division by zero")
+				} else if op == OpDiv {
+					push = []int64{a / b}
+				} else {
+					push = []int64{a % b}
+				}
+			case OpLt:
+				push = []int64{boolean(a < b)}
+			case OpGt:
+				push = []int64{boolean(a > b)}
+			case OpEq:
+				push = []int64{boolean(a == b)}
+			}
+		case OpNot:
+			var a int64
+			a, err = pop()
+			push = []int64{boolean(a == 0)}
+		case OpJump, OpJumpI:
+			var dest, cond int64
+			dest, err = pop()
+			if err == nil && op == OpJumpI {
+				cond, err = pop()
+			}
+			if err == nil && (op == OpJump || cond != 0) {
+				if dest < 0 || dest >= int64(len(code)) || !starts[int(dest)] {
+					err = fmt.Errorf("invalid jump to %d", dest)
+				}
+				next = int(dest)
+			}
+		case OpSLoad:
+			var key int64
+			key, err = pop()
+			value, ok := writes[key]
+			if !ok {
+				value = storage.Load(key)
+			}
+			push = []int64{value}
+		case OpSStore:
+			var key, value int64
+			key, value, err = pop2()
+			if err == nil {
+				writes[key] = value
+			}
+		case OpArg:
+			var i int64
+			i, err = pop()
+			if err == nil && (i < 0 || i >= int64(len(args))) {
+				err = fmt.Errorf("no argument %d", i)
+			}
+			if err == nil {
+				push = []int64{args[i]}
+			}
+		case OpArgc:
+			push = []int64{int64(len(args))}
+		case OpReturn:
+			var value int64
+			value, err = pop()
+			if err == nil {
+				return value, gasUsed, nil
+			}
+		case OpRevert:
+			return 0, gasUsed, ErrContractRevert
+		}
+		if err != nil {
+			return 0, gasUsed, err
+		}
+
+		if len(stack)+len(push) > maxStackDepth {
+			return 0, gasUsed, errors.New("stack overflow")
+		}
+		stack = append(stack, push...)
+		pc = next
+	}
+
+	return 0, gasUsed, nil
+}
+
+// AssembleContract translates contract assembly into code. Every line holds
+// one mnemonic, PUSH takes a number or a label, "name:" defines a label and
+// ";" starts a comment.
+func AssembleContract(source string) ([]byte, error) {
+	type instruction struct {
+		op      byte
+		operand string
+		line    int
+	}
+
+	var instructions []instruction
+	labels := make(map[string]int)
+	offset := 0
+
+	for i, line := range strings.Split(source, "\n") {
+		if comment := strings.Index(line, ";"); comment >= 0 {
+			line = line[:comment]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if len(fields) == 1 && strings.HasSuffix(fields[0], ":") {
+			label := strings.TrimSuffix(fields[0], ":")
+			if _, ok := labels[label]; ok {
+				return nil, fmt.Errorf("line %d: label %s defined twice", i+1, label)
+			}
+			labels[label] = offset
+			continue
+		}
+
+		opcode, ok := opcodes[strings.ToUpper(fields[0])]
+		if !ok {
+			return nil, fmt.Errorf("line %d: unknown mnemonic %s", i+1, fields[0])
+		}
+		if (opcode.op == OpPush) != (len(fields) == 2) || len(fields) > 2 {
+			return nil, fmt.Errorf("line %d: only PUSH takes an operand", i+1)
+		}
+
+		ins := instruction{op: opcode.op, line: i + 1}
+		offset++
+		if opcode.op == OpPush {
+			ins.operand = fields[1]
+			offset += 8
+		}
+		instructions = append(instructions, ins)
+	}
+
+	var code []byte
+	for _, ins := range instructions {
+		code = append(code, ins.op)
+		if ins.op != OpPush {
+			continue
+		}
+
+		value, err := strconv.ParseInt(ins.operand, 0, 64)
+		if err != nil {
+			target, ok := labels[ins.operand]
+			if !ok {
+				return nil, fmt.Errorf("line %d: %s is neither a number nor a label", ins.line, ins.operand)
+			}
+			value = int64(target)
+		}
+		code = binary.BigEndian.AppendUint64(code, uint64(value))
+	}
+
+	return code, nil
+}
@@ -17,16 +17,21 @@ const version = byte(0x00)
 const walletFile = "wallet.dat"
 const addressChecksumLen = 4
 
-// Wallet stores private and public keys
+// Wallet stores private and public keys. In an encrypted wallet file the
+// private key is only kept as EncryptedKey, PrivateKey.D is nil while the
+// wallet is locked. Path is the derivation path of HD keys and empty for
+// random keys.
 type Wallet struct {
-	PrivateKey ecdsa.PrivateKey
-	PublicKey  []byte
+	PrivateKey   ecdsa.PrivateKey
+	PublicKey    []byte
+	EncryptedKey []byte
+	Path         string
 }
 
 // NewWallet creates and returns a Wallet
 func NewWallet() *Wallet {
 	private, public := newKeyPair()
-	wallet := Wallet{*private, public}
+	wallet := Wallet{*private, public, nil, ""}
 
 	return &wallet
 }
@@ -35,6 +40,11 @@ func NewWallet() *Wallet {
 func (w Wallet) GetAddress() []byte {
 	pubKeyHash := HashPubKey(w.PublicKey)
 
+	return PubKeyHashToAddress(pubKeyHash)
+}
+
+// PubKeyHashToAddress returns the address of a public key hash
+func PubKeyHashToAddress(pubKeyHash []byte) []byte {
 	versionedPayload := append([]byte{version}, pubKeyHash...)
 	checksum := checksum(versionedPayload)
 
@@ -44,6 +54,13 @@ func (w Wallet) GetAddress() []byte {
 	return address
 }
 
+// AddressToPubKeyHash strips the version and checksum from an address
+func AddressToPubKeyHash(address string) []byte {
+	pubKeyHash := Base58Decode([]byte(address))
+
+	return pubKeyHash[1 : len(pubKeyHash)-addressChecksumLen]
+}
+
 // HashPubKey hashes public key
 func HashPubKey(pubKey []byte) []byte {
 	publicSHA256 := sha256.Sum256(pubKey)
@@ -83,15 +100,17 @@ func newKeyPair() (*ecdsa.PrivateKey, []byte) {
 	if err != nil {
 		log.Panic(err)
 	}
-	pubKey := append(private.PublicKey.X.Bytes(), private.PublicKey.Y.Bytes()...)
+	pubKey := encodePubKey(&private.PublicKey)
 
 	return private, pubKey
 }
 
 // Custom serialization methods to handle ecdsa.PrivateKey
 type walletGob struct {
-	D, X, Y []byte
-	PublicKey []byte
+	D, X, Y      []byte
+	PublicKey    []byte
+	EncryptedKey []byte
+	Path         string
 }
 
 func (w *Wallet) GobEncode() ([]byte, error) {
@@ -99,10 +118,14 @@ func (w *Wallet) GobEncode() ([]byte, error) {
 	encoder := gob.NewEncoder(&result)
 
 	data := walletGob{
-		D:        w.PrivateKey.D.Bytes(),
-		X:        w.PrivateKey.PublicKey.X.Bytes(),
-		Y:        w.PrivateKey.PublicKey.Y.Bytes(),
-		PublicKey: w.PublicKey,
+		X:            w.PrivateKey.PublicKey.X.Bytes(),
+		Y:            w.PrivateKey.PublicKey.Y.Bytes(),
+		PublicKey:    w.PublicKey,
+		EncryptedKey: w.EncryptedKey,
+		Path:         w.Path,
+	}
+	if w.PrivateKey.D != nil {
+		data.D = w.PrivateKey.D.Bytes()
 	}
 
 	err := encoder.Encode(data)
@@ -129,9 +152,13 @@ func (w *Wallet) GobDecode(data []byte) error {
 			X:     new(big.Int).SetBytes(decoded.X),
 			Y:     new(big.Int).SetBytes(decoded.Y),
 		},
-		D: new(big.Int).SetBytes(decoded.D),
+	}
+	if len(decoded.D) > 0 {
+		w.PrivateKey.D = new(big.Int).SetBytes(decoded.D)
 	}
 
 	w.PublicKey = decoded.PublicKey
+	w.EncryptedKey = decoded.EncryptedKey
+	w.Path = decoded.Path
 	return nil
 }
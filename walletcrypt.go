@@ -0,0 +1,408 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// ErrWalletLocked is returned by signing operations that need a private key
+// of an encrypted wallet file which is not unlocked
+var ErrWalletLocked = errors.New("wallet is locked, unlock it with walletpassphrase")
+
+// walletUnlockSocket is where the agent of an unlocked wallet file serves
+// the key to later commands until the unlock times out. The key is only
+// kept in the memory of the agent, any process of the user can ask for it
+// while the wallet is unlocked.
+const walletUnlockSocket = "wallet.unlock"
+
+// walletAgentCommand is the hidden command running the agent
+const walletAgentCommand = "walletagent"
+
+// The requests the agent answers, one per connection
+const (
+	walletAgentKey  = "key"
+	walletAgentLock = "lock"
+)
+
+// The scrypt cost parameters of new encrypted wallet files, deriving a key
+// takes 32 MiB of memory
+const walletKDFN = 1 << 15
+const walletKDFR = 8
+const walletKDFP = 1
+const walletKeyLen = 32
+const walletSaltLen = 16
+
+// walletCheck is sealed with the key of an encrypted wallet file so a
+// passphrase can be verified before any private key is decrypted
+var walletCheck = []byte("wallet passphrase check")
+
+// WalletEncryption holds the scrypt parameters of an encrypted wallet file
+// and Check, walletCheck sealed with the derived key. Private keys are
+// sealed with AES-256-GCM under that key, addresses, scripts and channels
+// stay readable while the wallet is locked.
+type WalletEncryption struct {
+	Salt  []byte
+	N     int
+	R     int
+	P     int
+	Check []byte
+}
+
+// deriveKey derives the wallet key from a passphrase
+func (we WalletEncryption) deriveKey(passphrase string) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), we.Salt, we.N, we.R, we.P, walletKeyLen)
+}
+
+// verifyKey checks that key is the wallet key
+func (we WalletEncryption) verifyKey(key []byte) bool {
+	check, err := walletOpen(key, we.Check)
+
+	return err == nil && bytes.Equal(check, walletCheck)
+}
+
+// walletSeal encrypts and authenticates plaintext, the random nonce is
+// prepended to the result
+func walletSeal(key, plaintext []byte) ([]byte, error) {
+	aead, err := newWalletAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// walletOpen decrypts data sealed by walletSeal
+func walletOpen(key, sealed []byte) ([]byte, error) {
+	aead, err := newWalletAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("encrypted data is too short")
+	}
+
+	return aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+}
+
+func newWalletAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// IsEncrypted checks whether the private keys of the wallet file are
+// encrypted
+func (ws Wallets) IsEncrypted() bool {
+	return ws.Encryption != nil
+}
+
+// IsLocked checks whether the wallet file is encrypted and not unlocked
+func (ws Wallets) IsLocked() bool {
+	return ws.Encryption != nil && ws.key == nil
+}
+
+// Encrypt encrypts the private keys of the wallet file with a key derived
+// from passphrase, they are only written encrypted from now on
+func (ws *Wallets) Encrypt(passphrase string) error {
+	if ws.Encryption != nil {
+		return errors.New("wallet is already encrypted")
+	}
+	if passphrase == "" {
+		return errors.New("passphrase must not be empty")
+	}
+
+	salt := make([]byte, walletSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	encryption := &WalletEncryption{salt, walletKDFN, walletKDFR, walletKDFP, nil}
+	key, err := encryption.deriveKey(passphrase)
+	if err != nil {
+		return err
+	}
+	if encryption.Check, err = walletSeal(key, walletCheck); err != nil {
+		return err
+	}
+
+	ws.Encryption = encryption
+	ws.key = key
+	return ws.encryptKeys()
+}
+
+// encryptKeys seals the private keys, the HD mnemonic and the MuSig secret
+// nonces that have no encrypted copy yet
+func (ws *Wallets) encryptKeys() error {
+	for _, wallet := range ws.Wallets {
+		if len(wallet.EncryptedKey) > 0 {
+			continue
+		}
+		if ws.key == nil || wallet.PrivateKey.D == nil {
+			return ErrWalletLocked
+		}
+
+		encrypted, err := walletSeal(ws.key, wallet.PrivateKey.D.FillBytes(make([]byte, coordinateLen)))
+		if err != nil {
+			return err
+		}
+		wallet.EncryptedKey = encrypted
+	}
+	if ws.HD != nil && len(ws.HD.EncryptedMnemonic) == 0 {
+		if ws.key == nil || ws.HD.Mnemonic == "" {
+			return ErrWalletLocked
+		}
+
+		encrypted, err := walletSeal(ws.key, []byte(ws.HD.Mnemonic))
+		if err != nil {
+			return err
+		}
+		ws.HD.EncryptedMnemonic = encrypted
+	}
+	for id, nonce := range ws.Nonces {
+		if _, ok := ws.EncryptedNonces[id]; ok {
+			continue
+		}
+		if ws.key == nil {
+			return ErrWalletLocked
+		}
+
+		encrypted, err := walletSeal(ws.key, nonce)
+		if err != nil {
+			return err
+		}
+		ws.EncryptedNonces[id] = encrypted
+	}
+
+	return nil
+}
+
+// decryptKeys opens the private keys, the HD mnemonic and the MuSig secret
+// nonces of the wallet file with key
+func (ws *Wallets) decryptKeys(key []byte) error {
+	for _, wallet := range ws.Wallets {
+		d, err := walletOpen(key, wallet.EncryptedKey)
+		if err != nil {
+			return err
+		}
+		wallet.PrivateKey.D = new(big.Int).SetBytes(d)
+	}
+	if ws.HD != nil {
+		mnemonic, err := walletOpen(key, ws.HD.EncryptedMnemonic)
+		if err != nil {
+			return err
+		}
+		ws.HD.Mnemonic = string(mnemonic)
+	}
+	for id, encrypted := range ws.EncryptedNonces {
+		nonce, err := walletOpen(key, encrypted)
+		if err != nil {
+			return err
+		}
+		ws.Nonces[id] = nonce
+	}
+	ws.key = key
+
+	return nil
+}
+
+// walletSession is the key of an unlocked wallet file and the unix time the
+// unlock ends at
+type walletSession struct {
+	Key   []byte
+	Until int64
+}
+
+// Unlock decrypts the private keys with the key derived from passphrase and
+// keeps them unlocked for timeout, for later commands as well. A background
+// agent holds the key until the unlock times out or the wallet is locked.
+func (ws *Wallets) Unlock(passphrase string, timeout time.Duration) error {
+	if ws.Encryption == nil {
+		return errors.New("wallet is not encrypted")
+	}
+	key, err := ws.Encryption.deriveKey(passphrase)
+	if err != nil {
+		return err
+	}
+	if !ws.Encryption.verifyKey(key) {
+		return errors.New("the passphrase is not correct")
+	}
+	if err := ws.decryptKeys(key); err != nil {
+		return err
+	}
+
+	if err := stopWalletAgent(); err != nil {
+		return err
+	}
+	return startWalletAgent(walletSession{key, time.Now().Add(timeout).Unix()})
+}
+
+// Lock forgets the private keys and ends the unlock
+func (ws *Wallets) Lock() error {
+	for _, wallet := range ws.Wallets {
+		if len(wallet.EncryptedKey) > 0 {
+			wallet.PrivateKey.D = nil
+		}
+	}
+	if ws.HD != nil && len(ws.HD.EncryptedMnemonic) > 0 {
+		ws.HD.Mnemonic = ""
+	}
+	for id := range ws.EncryptedNonces {
+		delete(ws.Nonces, id)
+	}
+	ws.key = nil
+
+	return stopWalletAgent()
+}
+
+// loadSession unlocks the wallet file with the key the agent of an unlock
+// that has not timed out yet holds
+func (ws *Wallets) loadSession() {
+	reply, err := askWalletAgent(walletAgentKey)
+	if err != nil {
+		return
+	}
+
+	key := []byte(reply)
+	if !ws.Encryption.verifyKey(key) {
+		return
+	}
+	if err := ws.decryptKeys(key); err != nil {
+		ws.Lock()
+	}
+}
+
+// askWalletAgent sends a request to the agent and returns its reply
+func askWalletAgent(request string) (string, error) {
+	conn, err := net.DialTimeout("unix", walletUnlockSocket, time.Second)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	if _, err := fmt.Fprintln(conn, request); err != nil {
+		return "", err
+	}
+	reply, err := ioutil.ReadAll(conn)
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// startWalletAgent runs the agent of a session in the background and waits
+// until it serves the key. The key reaches the agent through a pipe, it is
+// never written to disk.
+func startWalletAgent(session walletSession) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	var content bytes.Buffer
+	if err := gob.NewEncoder(&content).Encode(session); err != nil {
+		return err
+	}
+	agent := exec.Command(executable, walletAgentCommand)
+	agent.Stdin = &content
+	output, err := agent.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := agent.Start(); err != nil {
+		return err
+	}
+
+	status, err := bufio.NewReader(output).ReadString('\n')
+	if strings.TrimSpace(status) != "ready" {
+		agent.Wait()
+		return fmt.Errorf("the wallet agent did not start: %s %v", strings.TrimSpace(status), err)
+	}
+
+	return agent.Process.Release()
+}
+
+// stopWalletAgent ends the unlock of a running agent, a socket left behind
+// by an agent that is gone is removed
+func stopWalletAgent() error {
+	if _, err := askWalletAgent(walletAgentLock); err == nil {
+		return nil
+	}
+
+	if err := os.Remove(walletUnlockSocket); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// RunWalletAgent reads a session from in and serves its key on
+// walletUnlockSocket until the unlock times out or the wallet is locked,
+// then the socket is removed. Only the user can connect to the socket.
+func RunWalletAgent(in io.Reader, out io.Writer) error {
+	var session walletSession
+	if err := gob.NewDecoder(in).Decode(&session); err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("unix", walletUnlockSocket)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+	if err := os.Chmod(walletUnlockSocket, 0600); err != nil {
+		return err
+	}
+
+	timer := time.AfterFunc(time.Until(time.Unix(session.Until, 0)), func() { listener.Close() })
+	defer timer.Stop()
+	fmt.Fprintln(out, "ready")
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			// The listener is closed when the unlock ends
+			return nil
+		}
+
+		request, _ := bufio.NewReader(conn).ReadString('\n')
+		switch strings.TrimSpace(request) {
+		case walletAgentKey:
+			conn.Write(session.Key)
+		case walletAgentLock:
+			listener.Close()
+		}
+		conn.Close()
+	}
+}
+
+// writePrivateFile writes a file only its owner can read, an existing file
+// is restricted as well
+func writePrivateFile(name string, data []byte) error {
+	if err := ioutil.WriteFile(name, data, 0600); err != nil {
+		return err
+	}
+
+	return os.Chmod(name, 0600)
+}
@@ -3,21 +3,44 @@ package main
 import (
 	"bytes"
 	"encoding/gob"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
+	"sort"
 )
 
-// Wallets stores a collection of wallets
+// Wallets stores a collection of wallets and the redeem scripts of
+// script-hash addresses the wallet can sign for. AggregateKeys holds the
+// participant keys behind each aggregated Schnorr address, Nonces the
+// secret nonces of MuSig sessions waiting for the second round, sealed in
+// EncryptedNonces when the private keys are encrypted, and Channels the
+// payment channels between wallet addresses by ID. HD is the seed of
+// the deterministic keys, nil for wallet files of random keys only.
+// Encryption is set when the private keys are encrypted, key while they are
+// unlocked.
 type Wallets struct {
-	Wallets map[string]*Wallet
+	Wallets         map[string]*Wallet
+	Scripts         map[string][]byte
+	AggregateKeys   map[string][][]byte
+	Nonces          map[string][]byte
+	EncryptedNonces map[string][]byte
+	Channels        map[string]*Channel
+	HD              *HDChain
+	Encryption      *WalletEncryption
+	key             []byte
 }
 
 // NewWallets creates Wallets and fills it from a file if it exists
 func NewWallets() (*Wallets, error) {
 	wallets := Wallets{}
 	wallets.Wallets = make(map[string]*Wallet)
+	wallets.Scripts = make(map[string][]byte)
+	wallets.AggregateKeys = make(map[string][][]byte)
+	wallets.Nonces = make(map[string][]byte)
+	wallets.EncryptedNonces = make(map[string][]byte)
+	wallets.Channels = make(map[string]*Channel)
 
 	err := wallets.LoadFromFile()
 	return &wallets, err
@@ -38,9 +61,140 @@ func (ws *Wallets) GetAddresses() []string {
 	for address := range ws.Wallets {
 		addresses = append(addresses, address)
 	}
+	for address := range ws.Scripts {
+		addresses = append(addresses, address)
+	}
 	return addresses
 }
 
+// AddScript stores a redeem script and returns its address
+func (ws *Wallets) AddScript(script *RedeemScript) string {
+	address := fmt.Sprintf("%s", script.GetAddress())
+
+	ws.Scripts[address] = script.Serialize()
+	return address
+}
+
+// AddAggregateScript stores the Schnorr script of an aggregated key along
+// with its participant keys and returns its address
+func (ws *Wallets) AddAggregateScript(script *RedeemScript, keys [][]byte) string {
+	address := ws.AddScript(script)
+
+	ws.AggregateKeys[address] = keys
+	return address
+}
+
+// GetScript returns the redeem script stored for a script-hash address
+func (ws Wallets) GetScript(address string) (*RedeemScript, error) {
+	data, ok := ws.Scripts[address]
+	if !ok {
+		return nil, fmt.Errorf("no redeem script for address %s", address)
+	}
+
+	return DeserializeRedeemScript(data)
+}
+
+// FindByPubKeyHash returns the wallet whose public key hashes to
+// pubKeyHash or nil
+func (ws Wallets) FindByPubKeyHash(pubKeyHash []byte) *Wallet {
+	for _, wallet := range ws.Wallets {
+		if bytes.Equal(HashPubKey(wallet.PublicKey), pubKeyHash) {
+			return wallet
+		}
+	}
+
+	return nil
+}
+
+// FindByPubKey returns the wallet owning a public key in any of its
+// encodings or nil
+func (ws Wallets) FindByPubKey(pubKey []byte) *Wallet {
+	for _, wallet := range ws.Wallets {
+		for _, encoded := range pubKeyEncodings(&wallet.PrivateKey.PublicKey) {
+			if bytes.Equal(encoded, pubKey) {
+				return wallet
+			}
+		}
+	}
+
+	return nil
+}
+
+// MigrateKeys adds a compressed key wallet for every wallet still using a
+// legacy public key encoding and returns the new address of each legacy
+// address it added one for. The legacy wallets are kept so their coins stay
+// spendable.
+func (ws *Wallets) MigrateKeys() map[string]string {
+	migrated := make(map[string]string)
+
+	for address, newAddress := range ws.LegacyAddresses() {
+		if _, ok := ws.Wallets[newAddress]; ok {
+			continue
+		}
+
+		wallet := ws.Wallets[address]
+		ws.Wallets[newAddress] = &Wallet{wallet.PrivateKey, encodePubKey(&wallet.PrivateKey.PublicKey), wallet.EncryptedKey, wallet.Path}
+		migrated[address] = newAddress
+	}
+
+	return migrated
+}
+
+// LegacyAddresses returns the compressed key address of every wallet using
+// a legacy public key encoding, migrated or not
+func (ws Wallets) LegacyAddresses() map[string]string {
+	legacy := make(map[string]string)
+
+	for address, wallet := range ws.Wallets {
+		if len(wallet.PublicKey) == compressedPubKeyLen {
+			continue
+		}
+
+		compressed := Wallet{PublicKey: encodePubKey(&wallet.PrivateKey.PublicKey)}
+		legacy[address] = fmt.Sprintf("%s", compressed.GetAddress())
+	}
+
+	return legacy
+}
+
+// ProposerKey returns the wallet signing blocks that reward address: its
+// own key, a local key of its script, or the first local key otherwise
+func (ws Wallets) ProposerKey(address string) (*Wallet, error) {
+	if wallet, ok := ws.Wallets[address]; ok {
+		return wallet, nil
+	}
+
+	if script, err := ws.GetScript(address); err == nil {
+		keys := append(script.PubKeys, ws.AggregateKeys[address]...)
+		for _, pubKey := range keys {
+			if wallet := ws.FindByPubKey(pubKey); wallet != nil {
+				return wallet, nil
+			}
+		}
+	}
+
+	var addresses []string
+	for address := range ws.Wallets {
+		addresses = append(addresses, address)
+	}
+	if len(addresses) == 0 {
+		return nil, errors.New("no wallet key to sign blocks with")
+	}
+	sort.Strings(addresses)
+
+	return ws.Wallets[addresses[0]], nil
+}
+
+// GetChannel returns a payment channel by its ID
+func (ws Wallets) GetChannel(id string) (*Channel, error) {
+	channel, ok := ws.Channels[id]
+	if !ok {
+		return nil, fmt.Errorf("no channel %s in the wallet file", id)
+	}
+
+	return channel, nil
+}
+
 // GetWallet returns a Wallet by its address
 func (ws Wallets) GetWallet(address string) Wallet {
 	return *ws.Wallets[address]
@@ -66,22 +220,63 @@ func (ws *Wallets) LoadFromFile() error {
 	}
 
 	ws.Wallets = wallets.Wallets
+	if wallets.Scripts != nil {
+		ws.Scripts = wallets.Scripts
+	}
+	if wallets.AggregateKeys != nil {
+		ws.AggregateKeys = wallets.AggregateKeys
+	}
+	if wallets.Nonces != nil {
+		ws.Nonces = wallets.Nonces
+	}
+	if wallets.EncryptedNonces != nil {
+		ws.EncryptedNonces = wallets.EncryptedNonces
+	}
+	if wallets.Channels != nil {
+		ws.Channels = wallets.Channels
+	}
+	ws.HD = wallets.HD
+	ws.Encryption = wallets.Encryption
+	if ws.Encryption != nil {
+		ws.loadSession()
+	}
 	return nil
 }
 
-// SaveToFile saves wallets to a file
+// SaveToFile saves wallets to a file only its owner can read. The private
+// keys and secret nonces of an encrypted wallet are only written encrypted,
+// keys and nonces added since loading need the wallet unlocked.
 func (ws Wallets) SaveToFile() {
 	var content bytes.Buffer
 	gob.Register(&Wallet{}) // Register Wallet struct for gob encoding
 
+	if ws.Encryption != nil {
+		if err := ws.encryptKeys(); err != nil {
+			log.Panic(err)
+		}
+		wallets := make(map[string]*Wallet)
+		for address, wallet := range ws.Wallets {
+			locked := *wallet
+			locked.PrivateKey.D = nil
+			wallets[address] = &locked
+		}
+		ws.Wallets = wallets
+		if ws.HD != nil {
+			locked := *ws.HD
+			locked.Mnemonic = ""
+			ws.HD = &locked
+		}
+		ws.Nonces = nil
+	}
+
 	encoder := gob.NewEncoder(&content)
 	err := encoder.Encode(ws)
 	if err != nil {
 		log.Panic(err)
 	}
 
-	err = ioutil.WriteFile(walletFile, content.Bytes(), 0644)
+	err = writePrivateFile(walletFile, content.Bytes())
 	if err != nil {
 		log.Panic(err)
 	}
-}
\ No newline at end of file
+}